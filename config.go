@@ -11,11 +11,41 @@ type Config struct {
 	Title          string            `yaml:"title"`
 	Subtitle       string            `yaml:"subtitle"`
 	Email          string            `yaml:"email"`
+	Author         string            `yaml:"author"`
 	Description    string            `yaml:"description"`
 	Baseurl        string            `yaml:"baseurl"`
 	Url            string            `yaml:"url"`
 	GithubUsername string            `yaml:"github_username"`
+	Permalink      string            `yaml:"permalink"`
 	Custom         map[string]string `yaml:"custom"`
+	Include        []string          `yaml:"include"`
+	Exclude        []string          `yaml:"exclude"`
+
+	ExcerptSeparator string `yaml:"excerpt_separator"`
+	MaxIncludeDepth  int    `yaml:"max_include_depth"`
+	Strict           bool   `yaml:"strict"`
+
+	CategoryLayout    string `yaml:"category_layout"`
+	CategoryPermalink string `yaml:"category_permalink"`
+	CategoryPaginate  int    `yaml:"category_paginate"`
+	TagLayout         string `yaml:"tag_layout"`
+	TagPermalink      string `yaml:"tag_permalink"`
+	TagPaginate       int    `yaml:"tag_paginate"`
+
+	Feeds FeedsConfig `yaml:"feeds"`
+
+	Values map[string]interface{} `yaml:"-"`
+}
+
+// FeedsConfig is the feeds section of the config which sets what feed files are generated
+type FeedsConfig struct {
+	Atom       *bool  `yaml:"atom"`
+	Rss        bool   `yaml:"rss"`
+	Json       bool   `yaml:"json"`
+	Content    string `yaml:"content"`
+	Limit      int    `yaml:"limit"`
+	Categories bool   `yaml:"categories"`
+	Tags       bool   `yaml:"tags"`
 }
 
 func (c *Config) SetFromFile(p string) error {
@@ -33,9 +63,18 @@ func (c *Config) SetFromFile(p string) error {
 		return fmt.Errorf("Error setting config from YAML: %w", err)
 	}
 
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(b, &values); err != nil {
+		return fmt.Errorf("Error getting config values from YAML: %w", err)
+	}
+	c.Values = normalizeYamlValue(values).(map[string]interface{})
+
 	return nil
 }
 
 func (c *Config) Validate() error {
+	if c.Feeds.Content != "" && c.Feeds.Content != "full" && c.Feeds.Content != "excerpt" {
+		return fmt.Errorf("Invalid feeds content '%s', it should be either 'full' or 'excerpt'", c.Feeds.Content)
+	}
 	return nil
 }
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DataFile is a YAML, JSON or CSV file from the _data directory
+type DataFile struct {
+	Name  string
+	Value interface{}
+}
+
+func (d *DataFile) SetFromFile(fpath string) error {
+	b, err := os.ReadFile(fpath)
+	if err != nil {
+		return fmt.Errorf("Error reading file %s: %w", fpath, err)
+	}
+
+	ext := filepath.Ext(fpath)
+	d.Name = strings.TrimSuffix(filepath.Base(fpath), ext)
+
+	switch strings.ToLower(ext) {
+	case ".yml", ".yaml":
+		var v interface{}
+		if err := yaml.Unmarshal(b, &v); err != nil {
+			return fmt.Errorf("Error parsing YAML from %s: %w", fpath, err)
+		}
+		d.Value = normalizeYamlValue(v)
+	case ".json":
+		var v interface{}
+		if err := json.Unmarshal(b, &v); err != nil {
+			return fmt.Errorf("Error parsing JSON from %s: %w", fpath, err)
+		}
+		d.Value = v
+	case ".csv":
+		records, err := csv.NewReader(strings.NewReader(string(b))).ReadAll()
+		if err != nil {
+			return fmt.Errorf("Error parsing CSV from %s: %w", fpath, err)
+		}
+		rows := []interface{}{}
+		for i, record := range records {
+			if i == 0 {
+				continue
+			}
+			row := map[string]interface{}{}
+			for j, header := range records[0] {
+				if j < len(record) {
+					row[header] = record[j]
+				}
+			}
+			rows = append(rows, row)
+		}
+		d.Value = rows
+	default:
+		return fmt.Errorf("Unsupported data file %s", fpath)
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"strings"
+)
+
+// defaultExcerptSeparator makes the first paragraph an excerpt
+const defaultExcerptSeparator = "\n\n"
+
+// setExcerpts sets excerpts of all posts and pages
+func (g *Generator) setExcerpts(w *Website) error {
+	for _, name := range w.PostsNames {
+		if err := g.setExcerpt(w.Posts[name], w); err != nil {
+			return err
+		}
+	}
+	for _, name := range w.PageNames {
+		if err := g.setExcerpt(w.Pages[name], w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// setExcerpt sets excerpt of a page to its content up to the excerpt separator, which can be set in front
+// matter or config and defaults to an empty line.  When the separator is not found in the content, the
+// default one is used.  Excerpt can also be set directly in front matter.  Tags in the excerpt are processed
+// the same way as in the content.
+func (g *Generator) setExcerpt(p *Page, w *Website) error {
+	separator := defaultExcerptSeparator
+	if w.Config != nil && w.Config.ExcerptSeparator != "" {
+		separator = w.Config.ExcerptSeparator
+	}
+	if s, ok := p.FrontMatter["excerpt_separator"].(string); ok && s != "" {
+		separator = s
+	}
+
+	source, ok := p.FrontMatter["excerpt"].(string)
+	if !ok {
+		body := p.Body
+		if p.Path != "" {
+			body = annotateTagPositions(body, p.BodyLine)
+		}
+		body = strings.TrimLeft(body, " \t\n")
+		if !strings.Contains(body, separator) {
+			separator = defaultExcerptSeparator
+		}
+		source = strings.SplitN(body, separator, 2)[0]
+	}
+
+	excerpt := source
+	if p.ContentType == "markdown" {
+		excerpt = g.mdToHtml(source)
+	}
+
+	excerpt, err := g.processTags(excerpt, p.Path, w, p, nil)
+	if err != nil {
+		return fmt.Errorf("Error processing tags in excerpt of page %s: %w", p.Name, err)
+	}
+
+	p.Excerpt = strings.TrimSpace(excerpt)
+	p.ExcerptText = strings.Join(strings.Fields(html.UnescapeString(stripHtml(p.Excerpt))), " ")
+	return nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSetExcerpt(t *testing.T) {
+	w := &Website{
+		Config: &Config{
+			ExcerptSeparator: "<!--more-->",
+		},
+	}
+	g := &Generator{}
+
+	p := &Page{
+		ContentType: "markdown",
+		Body:        "\nFirst *paragraph* & more.\n\nSecond paragraph.\n<!--more-->\nRest.\n",
+	}
+	g.setExcerpt(p, w)
+	if p.Excerpt != "<p>First <em>paragraph</em> &amp; more.</p>\n\n<p>Second paragraph.</p>" {
+		t.Fatalf("setExcerpt set invalid excerpt: %s", p.Excerpt)
+	}
+	if p.ExcerptText != "First paragraph & more. Second paragraph." {
+		t.Fatalf("setExcerpt set invalid excerpt text: %s", p.ExcerptText)
+	}
+
+	p.Body = "\nFirst *paragraph* & more.\n\nSecond paragraph.\n"
+	g.setExcerpt(p, w)
+	if p.Excerpt != "<p>First <em>paragraph</em> &amp; more.</p>" {
+		t.Fatalf("setExcerpt did not fall back to the default separator: %s", p.Excerpt)
+	}
+
+	w.Config.ExcerptSeparator = ""
+	g.setExcerpt(p, w)
+	if p.Excerpt != "<p>First <em>paragraph</em> &amp; more.</p>" {
+		t.Fatalf("setExcerpt set invalid excerpt with the default separator: %s", p.Excerpt)
+	}
+
+	p.FrontMatter = map[string]interface{}{
+		"excerpt_separator": "more.",
+	}
+	g.setExcerpt(p, w)
+	if p.ExcerptText != "First paragraph &" {
+		t.Fatalf("setExcerpt set invalid excerpt with separator from front matter: %s", p.ExcerptText)
+	}
+
+	p.FrontMatter = map[string]interface{}{
+		"excerpt": "Custom **excerpt**",
+	}
+	g.setExcerpt(p, w)
+	if p.Excerpt != "<p>Custom <strong>excerpt</strong></p>" || g.getPageVariables(p)["excerpt_text"] != "Custom excerpt" {
+		t.Fatalf("setExcerpt set invalid excerpt from front matter: %s", p.Excerpt)
+	}
+}
+
+func TestGenerateExcerptsInPostLists(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+	for name, body := range map[string]string{
+		"_config.yml":                    "title: Site\nurl: http://example.com\n",
+		"_layouts/default.html":          "{{ content }}",
+		"_layouts/category.html":         "{% for post in page.posts %}[{{ post.excerpt_text }}]{% endfor %}",
+		"_includes/name.html":            "{{ include.name }}",
+		"_posts/2023-01-01-one.markdown": "---\nlayout: default\ntitle: One\ncategories: news\n---\nFirst excerpt of {% include name.html name=\"Joe\" %} on {{ site.title }}.\n\nRest.\n",
+		"_posts/2023-02-01-two.markdown": "---\nlayout: default\ntitle: Two\ncategories: news\n---\nSecond excerpt.\n\nRest.\n",
+		"index.markdown":                 "---\nlayout: default\npaginate: 5\n---\n{% for post in paginator.posts %}[{{ post.excerpt_text }}]{% endfor %}",
+	} {
+		os.MkdirAll(filepath.Dir(filepath.Join(src, name)), 0750)
+		os.WriteFile(filepath.Join(src, name), []byte(body), 0640)
+	}
+
+	w := &Website{SourcePath: src}
+	if err := w.Init(); err != nil {
+		t.Fatalf("Init returned error: %s", err.Error())
+	}
+	g := &Generator{DestinationPath: dst}
+	if err := g.Generate(w); err != nil {
+		t.Fatalf("Generate returned error: %s", err.Error())
+	}
+
+	for file, expected := range map[string]string{
+		"index.html":               "[Second excerpt.][First excerpt of Joe on Site.]",
+		"category/news/index.html": "[Second excerpt.][First excerpt of Joe on Site.]",
+		"feed.xml":                 "<summary type=\"text\">First excerpt of Joe on Site.</summary>",
+	} {
+		b, err := os.ReadFile(filepath.Join(dst, file))
+		if err != nil {
+			t.Fatalf("Generate did not write %s: %s", file, err.Error())
+		}
+		if !strings.Contains(string(b), expected) {
+			t.Fatalf("Generate wrote invalid excerpts in %s: %s", file, string(b))
+		}
+	}
+}
@@ -0,0 +1,274 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Expression is a condition used in if, unless and elsif tags, eg. 'page.title == "Home" and site.posts'.
+// It supports comparison operators (==, !=, <, >, <=, >=, contains), 'and', 'or', parentheses, variables
+// and string, number, boolean and nil literals.
+type Expression struct {
+	Source string
+
+	tokens []exprToken
+	pos    int
+	root   *exprNode
+}
+
+type exprToken struct {
+	Type  string
+	Value string
+}
+
+type exprNode struct {
+	Op    string
+	Value interface{}
+	Left  *exprNode
+	Right *exprNode
+}
+
+// emptyValue is value of 'empty' and 'blank' keywords which equal to empty strings, lists and maps
+type emptyValue struct{}
+
+func (e *Expression) Parse() error {
+	if err := e.tokenize(); err != nil {
+		return err
+	}
+	if len(e.tokens) == 0 {
+		return fmt.Errorf("Empty expression")
+	}
+
+	e.pos = 0
+	root, err := e.parseOr()
+	if err != nil {
+		return err
+	}
+	if e.pos < len(e.tokens) {
+		return fmt.Errorf("Unexpected '%s' in expression '%s'", e.tokens[e.pos].Value, e.Source)
+	}
+	e.root = root
+
+	return nil
+}
+
+// Evaluate returns value of the expression, using lookup func to get values of variables
+func (e *Expression) Evaluate(lookup func(string) (interface{}, bool)) interface{} {
+	return e.evaluateNode(e.root, lookup)
+}
+
+func (e *Expression) tokenize() error {
+	e.tokens = []exprToken{}
+
+	re := regexp.MustCompile(`^(?:` +
+		`(?P<space>\s+)|` +
+		`(?P<paren>[\(\)])|` +
+		`(?P<string>"[^"]*"|'[^']*')|` +
+		`(?P<op>==|!=|<>|<=|>=|<|>)|` +
+		`(?P<number>-?[0-9]+(?:\.[0-9]+)?)|` +
+		`(?P<word>[a-zA-Z_][a-zA-Z0-9\-\_]*(?:\.[a-zA-Z0-9\-\_]+|\[[^\]]+\])*)` +
+		`)`)
+	names := re.SubexpNames()
+
+	s := e.Source
+	for len(s) > 0 {
+		found := re.FindStringSubmatchIndex(s)
+		if found == nil {
+			return fmt.Errorf("Unexpected character '%s' in expression '%s'", string([]rune(s)[0]), e.Source)
+		}
+		for i := 1; i < len(names); i++ {
+			if found[2*i] == -1 {
+				continue
+			}
+			value := s[found[2*i]:found[2*i+1]]
+			switch names[i] {
+			case "space":
+			case "word":
+				switch value {
+				case "and", "or", "contains":
+					e.tokens = append(e.tokens, exprToken{Type: "op", Value: value})
+				default:
+					e.tokens = append(e.tokens, exprToken{Type: "word", Value: value})
+				}
+			default:
+				e.tokens = append(e.tokens, exprToken{Type: names[i], Value: value})
+			}
+			break
+		}
+		s = s[found[1]:]
+	}
+
+	return nil
+}
+
+func (e *Expression) parseOr() (*exprNode, error) {
+	left, err := e.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for e.isNextOp("or") {
+		e.pos++
+		right, err := e.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprNode{Op: "or", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (e *Expression) parseAnd() (*exprNode, error) {
+	left, err := e.parseComparison()
+	if err != nil {
+		return nil, err
+	}
+	for e.isNextOp("and") {
+		e.pos++
+		right, err := e.parseComparison()
+		if err != nil {
+			return nil, err
+		}
+		left = &exprNode{Op: "and", Left: left, Right: right}
+	}
+	return left, nil
+}
+
+func (e *Expression) parseComparison() (*exprNode, error) {
+	left, err := e.parseOperand()
+	if err != nil {
+		return nil, err
+	}
+	if e.pos < len(e.tokens) && e.tokens[e.pos].Type == "op" && !e.isNextOp("and") && !e.isNextOp("or") {
+		op := e.tokens[e.pos].Value
+		e.pos++
+		right, err := e.parseOperand()
+		if err != nil {
+			return nil, err
+		}
+		return &exprNode{Op: op, Left: left, Right: right}, nil
+	}
+	return left, nil
+}
+
+func (e *Expression) parseOperand() (*exprNode, error) {
+	if e.pos >= len(e.tokens) {
+		return nil, fmt.Errorf("Unexpected end of expression '%s'", e.Source)
+	}
+
+	t := e.tokens[e.pos]
+	e.pos++
+
+	switch t.Type {
+	case "paren":
+		if t.Value != "(" {
+			return nil, fmt.Errorf("Unexpected ')' in expression '%s'", e.Source)
+		}
+		node, err := e.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if e.pos >= len(e.tokens) || e.tokens[e.pos].Value != ")" {
+			return nil, fmt.Errorf("Missing ')' in expression '%s'", e.Source)
+		}
+		e.pos++
+		return node, nil
+	case "string":
+		return &exprNode{Op: "literal", Value: t.Value[1 : len(t.Value)-1]}, nil
+	case "number":
+		if i, err := strconv.Atoi(t.Value); err == nil {
+			return &exprNode{Op: "literal", Value: i}, nil
+		}
+		f, _ := strconv.ParseFloat(t.Value, 64)
+		return &exprNode{Op: "literal", Value: f}, nil
+	case "word":
+		switch t.Value {
+		case "true":
+			return &exprNode{Op: "literal", Value: true}, nil
+		case "false":
+			return &exprNode{Op: "literal", Value: false}, nil
+		case "nil", "null":
+			return &exprNode{Op: "literal", Value: nil}, nil
+		case "empty", "blank":
+			return &exprNode{Op: "literal", Value: emptyValue{}}, nil
+		}
+		return &exprNode{Op: "variable", Value: t.Value}, nil
+	}
+
+	return nil, fmt.Errorf("Unexpected '%s' in expression '%s'", t.Value, e.Source)
+}
+
+func (e *Expression) isNextOp(op string) bool {
+	return e.pos < len(e.tokens) && e.tokens[e.pos].Type == "op" && e.tokens[e.pos].Value == op
+}
+
+func (e *Expression) evaluateNode(n *exprNode, lookup func(string) (interface{}, bool)) interface{} {
+	switch n.Op {
+	case "literal":
+		return n.Value
+	case "variable":
+		v, _ := lookup(n.Value.(string))
+		return v
+	case "or":
+		return isTruthy(e.evaluateNode(n.Left, lookup)) || isTruthy(e.evaluateNode(n.Right, lookup))
+	case "and":
+		return isTruthy(e.evaluateNode(n.Left, lookup)) && isTruthy(e.evaluateNode(n.Right, lookup))
+	}
+
+	left := e.evaluateNode(n.Left, lookup)
+	right := e.evaluateNode(n.Right, lookup)
+	switch n.Op {
+	case "==":
+		return valuesEqual(left, right)
+	case "!=", "<>":
+		return !valuesEqual(left, right)
+	case "<":
+		return compareValues(left, right) < 0
+	case ">":
+		return compareValues(left, right) > 0
+	case "<=":
+		return compareValues(left, right) <= 0
+	case ">=":
+		return compareValues(left, right) >= 0
+	case "contains":
+		return valueContains(left, right)
+	}
+
+	return nil
+}
+
+func valuesEqual(a interface{}, b interface{}) bool {
+	if _, ok := a.(emptyValue); ok {
+		return !isTruthy(b)
+	}
+	if _, ok := b.(emptyValue); ok {
+		return !isTruthy(a)
+	}
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	_, aok := valueToFloat(a)
+	_, bok := valueToFloat(b)
+	if aok && bok {
+		return compareValues(a, b) == 0
+	}
+	return valueToString(a) == valueToString(b)
+}
+
+func valueContains(container interface{}, item interface{}) bool {
+	switch val := container.(type) {
+	case string:
+		return strings.Contains(val, valueToString(item))
+	case []interface{}:
+		for _, v := range val {
+			if valuesEqual(v, item) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		_, ok := val[valueToString(item)]
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestExpression(t *testing.T) {
+	vars := map[string]interface{}{
+		"page": map[string]interface{}{
+			"title": "Home",
+			"count": "10",
+			"tags":  []interface{}{"go", "web"},
+			"empty": "",
+		},
+		"site": map[string]interface{}{
+			"title": "Site",
+			"posts": []interface{}{"a", "b"},
+		},
+	}
+	lookup := func(name string) (interface{}, bool) {
+		path := splitVariablePath(name)
+		return getPathValue(vars, path)
+	}
+
+	for source, expected := range map[string]bool{
+		`page.title`:                                     true,
+		`page.missing`:                                   false,
+		`page.title == "Home"`:                           true,
+		`page.title == 'Other'`:                          false,
+		`page.title != "Other"`:                          true,
+		`page.count > 9`:                                 true,
+		`page.count >= 10`:                               true,
+		`page.count < 9.5`:                               false,
+		`page.count <= 10`:                               true,
+		`page.tags contains "go"`:                        true,
+		`page.tags contains "rust"`:                      false,
+		`page.title contains "om"`:                       true,
+		`page.title == "Home" and site.title == "Site"`:  true,
+		`page.title == "Home" and site.title == "Other"`: false,
+		`page.missing or site.title`:                     true,
+		`page.missing or site.missing`:                   false,
+		`(page.missing or site.title) and page.count`:    true,
+		`page.missing or (site.title and site.missing)`:  false,
+		`site.posts.size == 2`:                           true,
+		`page.empty == empty`:                            true,
+		`site.posts == empty`:                            false,
+		`page.missing == nil`:                            true,
+		`true and 1`:                                     true,
+		`false`:                                          false,
+	} {
+		e := &Expression{Source: source}
+		if err := e.Parse(); err != nil {
+			t.Fatalf("Parse returned error for %s: %s", source, err.Error())
+		}
+		if isTruthy(e.Evaluate(lookup)) != expected {
+			t.Fatalf("Evaluate returned invalid result for %s", source)
+		}
+	}
+
+	for _, source := range []string{
+		``,
+		`page.title ==`,
+		`(page.title`,
+		`page.title)`,
+		`page.title == "Home" and`,
+		`page.title = "Home"`,
+		`page.title "Home"`,
+	} {
+		e := &Expression{Source: source}
+		if err := e.Parse(); err == nil {
+			t.Fatalf("Parse failed to return error for %s", source)
+		}
+	}
+}
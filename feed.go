@@ -0,0 +1,430 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const defaultFeedLimit = 20
+
+// feed is a list of posts written to feed files, eg. /feed.xml for all posts or /category/golang/feed.xml
+type feed struct {
+	Dir   string
+	Url   string
+	Title string
+	Posts []*Page
+}
+
+// feedItem is a post with its values prepared for feeds
+type feedItem struct {
+	Url       string
+	Title     string
+	Content   string
+	Summary   string
+	Published time.Time
+	Updated   time.Time
+	Author    string
+	AuthorUrl string
+	Tags      []string
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Id       string      `xml:"id"`
+	Updated  string      `xml:"updated"`
+	Links    []atomLink  `xml:"link"`
+	Author   *atomPerson `xml:"author,omitempty"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomPerson struct {
+	Name  string `xml:"name"`
+	Email string `xml:"email,omitempty"`
+	Uri   string `xml:"uri,omitempty"`
+}
+
+type atomText struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type atomEntry struct {
+	Title      string         `xml:"title"`
+	Id         string         `xml:"id"`
+	Link       atomLink       `xml:"link"`
+	Published  string         `xml:"published"`
+	Updated    string         `xml:"updated"`
+	Author     *atomPerson    `xml:"author,omitempty"`
+	Summary    *atomText      `xml:"summary,omitempty"`
+	Content    *atomText      `xml:"content,omitempty"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssGuid struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Guid        rssGuid  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Description string   `xml:"description"`
+	Categories  []string `xml:"category"`
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageUrl string         `json:"home_page_url"`
+	FeedUrl     string         `json:"feed_url"`
+	Description string         `json:"description,omitempty"`
+	Authors     []jsonAuthor   `json:"authors,omitempty"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonAuthor struct {
+	Name string `json:"name"`
+	Url  string `json:"url,omitempty"`
+}
+
+type jsonFeedItem struct {
+	Id            string       `json:"id"`
+	Url           string       `json:"url"`
+	Title         string       `json:"title"`
+	ContentHtml   string       `json:"content_html"`
+	Summary       string       `json:"summary,omitempty"`
+	DatePublished string       `json:"date_published"`
+	DateModified  string       `json:"date_modified,omitempty"`
+	Authors       []jsonAuthor `json:"authors,omitempty"`
+	Tags          []string     `json:"tags,omitempty"`
+}
+
+// generateFeeds writes feed files for all posts and, when enabled in config, for every category and tag.
+// Feed files that exist in the source directory are not generated.
+func (g *Generator) generateFeeds(w *Website) error {
+	atomEnabled := w.Config.Feeds.Atom == nil || *w.Config.Feeds.Atom
+	rssEnabled := w.Config.Feeds.Rss
+	jsonEnabled := w.Config.Feeds.Json
+	if !atomEnabled && !rssEnabled && !jsonEnabled {
+		return nil
+	}
+
+	feeds, err := g.getFeeds(w)
+	if err != nil {
+		return err
+	}
+
+	items := map[*Page]*feedItem{}
+	for _, f := range feeds {
+		feedItems := []*feedItem{}
+		for _, post := range f.Posts {
+			item, ok := items[post]
+			if !ok {
+				item, err = g.getFeedItem(post, w)
+				if err != nil {
+					return fmt.Errorf("Error getting feed item for post %s: %w", post.Name, err)
+				}
+				items[post] = item
+			}
+			feedItems = append(feedItems, item)
+		}
+
+		if atomEnabled {
+			if err := g.writeFeedFile(f.Dir+"feed.xml", g.getAtomFeed(f, feedItems, w), w); err != nil {
+				return err
+			}
+		}
+		if rssEnabled {
+			if err := g.writeFeedFile(f.Dir+"rss.xml", g.getRssFeed(f, feedItems, w), w); err != nil {
+				return err
+			}
+		}
+		if jsonEnabled {
+			if err := g.writeFeedFile(f.Dir+"feed.json", g.getJsonFeed(f, feedItems, w), w); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// getFeeds returns the feed of all posts followed by category and tag feeds that are enabled in config
+func (g *Generator) getFeeds(w *Website) ([]*feed, error) {
+	limit := w.Config.Feeds.Limit
+	if limit <= 0 {
+		limit = defaultFeedLimit
+	}
+	limitPosts := func(posts []*Page) []*Page {
+		if len(posts) > limit {
+			return posts[:limit]
+		}
+		return posts
+	}
+
+	posts := []*Page{}
+	for _, name := range w.PostsNames {
+		posts = append(posts, w.Posts[name])
+	}
+	feeds := []*feed{
+		&feed{
+			Dir:   "/",
+			Url:   "/",
+			Title: w.Config.Title,
+			Posts: limitPosts(posts),
+		},
+	}
+
+	for _, t := range g.getTaxonomies(w) {
+		if (t.Kind == "category" && !w.Config.Feeds.Categories) || (t.Kind == "tag" && !w.Config.Feeds.Tags) {
+			continue
+		}
+		names, taxonomyPosts := g.getTaxonomyPosts(t, w)
+		for _, name := range names {
+			url, err := g.getTaxonomyUrl(t, name)
+			if err != nil {
+				return nil, err
+			}
+			feeds = append(feeds, &feed{
+				Dir:   g.getUrlDir(url),
+				Url:   url,
+				Title: strings.Trim(fmt.Sprintf("%s - %s", w.Config.Title, name), " -"),
+				Posts: limitPosts(taxonomyPosts[name]),
+			})
+		}
+	}
+
+	return feeds, nil
+}
+
+func (g *Generator) getFeedItem(p *Page, w *Website) (*feedItem, error) {
+	item := &feedItem{
+		Url:       g.getAbsoluteUrl(p.Url, w),
+		Title:     p.Title,
+		Summary:   p.Description,
+		Published: p.Time,
+		Updated:   p.Time,
+		Author:    p.Author,
+		AuthorUrl: p.AuthorLink,
+		Tags:      append(p.GetCategories(), p.GetTags()...),
+	}
+	if item.Title == "" {
+		item.Title = p.Name
+	}
+	if t, ok := valueToTime(p.FrontMatter["last_modified_at"]); ok {
+		item.Updated = t
+	}
+
+	if item.Summary == "" {
+		item.Summary = p.ExcerptText
+	}
+
+	if w.Config.Feeds.Content == "excerpt" {
+		item.Content = p.Excerpt
+	} else {
+		content, err := g.getPageContentHtml(p, w)
+		if err != nil {
+			return nil, err
+		}
+		item.Content = content
+	}
+
+	return item, nil
+}
+
+// getPageContentHtml returns HTML of the page content without the layout, with absolute links
+func (g *Generator) getPageContentHtml(p *Page, w *Website) (string, error) {
+	contentHtml, err := g.getContentHtml(p, w, w.Layouts[p.Layout])
+	if err != nil {
+		return "", err
+	}
+
+	re := regexp.MustCompile(`(href|src)="/`)
+	contentHtml = re.ReplaceAllString(contentHtml, fmt.Sprintf(`$1="%s/`, g.getAbsoluteUrl("", w)))
+
+	return contentHtml, nil
+}
+
+func (g *Generator) getFeedUpdated(items []*feedItem) time.Time {
+	updated := time.Time{}
+	for _, item := range items {
+		if item.Updated.After(updated) {
+			updated = item.Updated
+		}
+	}
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+	return updated
+}
+
+func (g *Generator) getAtomFeed(f *feed, items []*feedItem, w *Website) interface{} {
+	atom := &atomFeed{
+		Title:    f.Title,
+		Subtitle: w.Config.Description,
+		Id:       g.getAbsoluteUrl(f.Url, w),
+		Updated:  g.getFeedUpdated(items).Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: g.getAbsoluteUrl(f.Dir+"feed.xml", w), Rel: "self", Type: "application/atom+xml"},
+			{Href: g.getAbsoluteUrl(f.Url, w), Rel: "alternate", Type: "text/html"},
+		},
+		Entries: []atomEntry{},
+	}
+	if w.Config.Author != "" {
+		atom.Author = &atomPerson{Name: w.Config.Author, Email: w.Config.Email}
+	}
+
+	for _, item := range items {
+		entry := atomEntry{
+			Title:      item.Title,
+			Id:         item.Url,
+			Link:       atomLink{Href: item.Url, Rel: "alternate", Type: "text/html"},
+			Published:  item.Published.Format(time.RFC3339),
+			Updated:    item.Updated.Format(time.RFC3339),
+			Categories: []atomCategory{},
+		}
+		if item.Author != "" {
+			entry.Author = &atomPerson{Name: item.Author, Uri: item.AuthorUrl}
+		} else if atom.Author == nil {
+			// atom requires an author either in the feed or in every entry
+			entry.Author = &atomPerson{Name: f.Title}
+		}
+		if item.Summary != "" {
+			entry.Summary = &atomText{Type: "text", Body: item.Summary}
+		}
+		if item.Content != "" {
+			entry.Content = &atomText{Type: "html", Body: item.Content}
+		}
+		for _, tag := range item.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+		atom.Entries = append(atom.Entries, entry)
+	}
+
+	return atom
+}
+
+func (g *Generator) getRssFeed(f *feed, items []*feedItem, w *Website) interface{} {
+	rss := &rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         f.Title,
+			Link:          g.getAbsoluteUrl(f.Url, w),
+			Description:   w.Config.Description,
+			LastBuildDate: g.getFeedUpdated(items).Format(time.RFC1123Z),
+			Items:         []rssItem{},
+		},
+	}
+
+	for _, item := range items {
+		description := item.Content
+		if description == "" {
+			description = item.Summary
+		}
+		rss.Channel.Items = append(rss.Channel.Items, rssItem{
+			Title:       item.Title,
+			Link:        item.Url,
+			Guid:        rssGuid{IsPermaLink: "true", Value: item.Url},
+			PubDate:     item.Published.Format(time.RFC1123Z),
+			Description: description,
+			Categories:  item.Tags,
+		})
+	}
+
+	return rss
+}
+
+func (g *Generator) getJsonFeed(f *feed, items []*feedItem, w *Website) interface{} {
+	feed := &jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       f.Title,
+		HomePageUrl: g.getAbsoluteUrl(f.Url, w),
+		FeedUrl:     g.getAbsoluteUrl(f.Dir+"feed.json", w),
+		Description: w.Config.Description,
+		Items:       []jsonFeedItem{},
+	}
+	if w.Config.Author != "" {
+		feed.Authors = []jsonAuthor{{Name: w.Config.Author}}
+	}
+
+	for _, item := range items {
+		jsonItem := jsonFeedItem{
+			Id:            item.Url,
+			Url:           item.Url,
+			Title:         item.Title,
+			ContentHtml:   item.Content,
+			Summary:       item.Summary,
+			DatePublished: item.Published.Format(time.RFC3339),
+			Tags:          item.Tags,
+		}
+		if !item.Updated.Equal(item.Published) {
+			jsonItem.DateModified = item.Updated.Format(time.RFC3339)
+		}
+		if item.Author != "" {
+			jsonItem.Authors = []jsonAuthor{{Name: item.Author, Url: item.AuthorUrl}}
+		}
+		feed.Items = append(feed.Items, jsonItem)
+	}
+
+	return feed
+}
+
+// writeFeedFile writes v to url as JSON or XML, depending on the extension
+func (g *Generator) writeFeedFile(url string, v interface{}, w *Website) error {
+	if g.hasSourceFile(url, w) {
+		return nil
+	}
+
+	var s string
+	if path.Ext(url) == ".json" {
+		b, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("Error generating %s: %w", url, err)
+		}
+		s = string(b) + "\n"
+	} else {
+		b, err := xml.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("Error generating %s: %w", url, err)
+		}
+		s = xml.Header + string(b) + "\n"
+	}
+
+	if err := g.writeUrlFile(url, s); err != nil {
+		return fmt.Errorf("Error writing %s: %w", url, err)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateFeeds(t *testing.T) {
+	dst := t.TempDir()
+	atom := false
+	w := &Website{
+		SourcePath: t.TempDir(),
+		Config: &Config{
+			Title:   "Title",
+			Url:     "http://example.com",
+			Baseurl: "blog",
+			Feeds: FeedsConfig{
+				Atom:    &atom,
+				Rss:     true,
+				Content: "excerpt",
+				Limit:   1,
+				Tags:    true,
+			},
+		},
+		Posts: map[string]*Page{
+			"2023-01-01-one": &Page{Name: "2023-01-01-one", Title: "One", Url: "/one/", Description: "First <b>post</b>",
+				Tags: StringList{"go"}, Time: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+			"2022-01-01-two": &Page{Name: "2022-01-01-two", Title: "Two", Url: "/two/", Description: "Second",
+				Tags: StringList{"go", "web"}, Time: time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		PostsNames: []string{"2023-01-01-one", "2022-01-01-two"},
+	}
+	g := &Generator{
+		DestinationPath: dst,
+	}
+
+	if err := g.generateFeeds(w); err != nil {
+		t.Fatalf("generateFeeds returned error: %s", err.Error())
+	}
+
+	if _, err := os.Stat(filepath.Join(dst, "feed.xml")); !os.IsNotExist(err) {
+		t.Fatalf("generateFeeds generated atom feed when it is disabled")
+	}
+
+	b, err := os.ReadFile(filepath.Join(dst, "rss.xml"))
+	if err != nil {
+		t.Fatalf("generateFeeds did not generate rss.xml: %s", err.Error())
+	}
+	rss := string(b)
+	if !strings.Contains(rss, "<link>http://example.com/blog/one/</link>") ||
+		!strings.Contains(rss, "<description>First &lt;b&gt;post&lt;/b&gt;</description>") ||
+		!strings.Contains(rss, "<pubDate>Sun, 01 Jan 2023 00:00:00 +0000</pubDate>") ||
+		strings.Contains(rss, "Two") {
+		t.Fatalf("generateFeeds generated invalid rss.xml: %s", rss)
+	}
+
+	b, err = os.ReadFile(filepath.Join(dst, "tag", "web", "rss.xml"))
+	if err != nil {
+		t.Fatalf("generateFeeds did not generate tag feed: %s", err.Error())
+	}
+	if !strings.Contains(string(b), "<title>Title - web</title>") || !strings.Contains(string(b), "<title>Two</title>") {
+		t.Fatalf("generateFeeds generated invalid tag feed: %s", string(b))
+	}
+}
+
+func TestGenerateJsonFeed(t *testing.T) {
+	dst := t.TempDir()
+	atom := false
+	w := &Website{
+		SourcePath: t.TempDir(),
+		Config: &Config{
+			Title:  "Title",
+			Url:    "http://example.com",
+			Author: "Site Author",
+			Feeds: FeedsConfig{
+				Atom: &atom,
+				Json: true,
+			},
+		},
+		Posts: map[string]*Page{
+			"2023-01-01-one": &Page{Name: "2023-01-01-one", Title: "One", Url: "/one/", ContentType: "html",
+				Body: `<a href="/two/">Two</a>`, Description: "First", Author: "Author", Tags: StringList{"go"},
+				Time: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		PostsNames: []string{"2023-01-01-one"},
+	}
+	g := &Generator{
+		DestinationPath: dst,
+	}
+
+	if err := g.generateFeeds(w); err != nil {
+		t.Fatalf("generateFeeds returned error: %s", err.Error())
+	}
+
+	b, err := os.ReadFile(filepath.Join(dst, "feed.json"))
+	if err != nil {
+		t.Fatalf("generateFeeds did not generate feed.json: %s", err.Error())
+	}
+
+	feed := &jsonFeed{}
+	if err := json.Unmarshal(b, feed); err != nil {
+		t.Fatalf("generateFeeds generated invalid JSON: %s", err.Error())
+	}
+	if feed.Version != "https://jsonfeed.org/version/1.1" || feed.FeedUrl != "http://example.com/feed.json" ||
+		len(feed.Authors) != 1 || len(feed.Items) != 1 {
+		t.Fatalf("generateFeeds generated invalid feed.json: %s", string(b))
+	}
+	item := feed.Items[0]
+	if item.Id != "http://example.com/one/" || item.ContentHtml != `<a href="http://example.com/two/">Two</a>` ||
+		item.Summary != "First" || item.DatePublished != "2023-01-01T00:00:00Z" || item.Authors[0].Name != "Author" ||
+		len(item.Tags) != 1 {
+		t.Fatalf("generateFeeds generated invalid feed.json item: %s", string(b))
+	}
+}
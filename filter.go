@@ -0,0 +1,437 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter transforms value in output tag, eg. 'upcase' in '{{ page.title | upcase }}'.  Args are values of
+// arguments passed after colon, eg. 40 and "..." in 'truncate: 40, "..."'.
+type Filter func(input interface{}, args []interface{}) (interface{}, error)
+
+// Output is contents of {{ }} tag, ie. a value followed by optional filters, eg. 'page.title | truncate: 40'
+type Output struct {
+	Source string
+
+	value   *Expression
+	filters []outputFilter
+}
+
+type outputFilter struct {
+	Name string
+	Args []*Expression
+}
+
+func (o *Output) Parse() error {
+	parts := splitOutsideQuotes(o.Source, '|')
+
+	o.value = &Expression{
+		Source: strings.TrimSpace(parts[0]),
+	}
+	if err := o.value.Parse(); err != nil {
+		return err
+	}
+	if o.value.root.Op != "literal" && o.value.root.Op != "variable" {
+		return fmt.Errorf("Output '%s' must be a variable or a literal", o.value.Source)
+	}
+
+	o.filters = []outputFilter{}
+	for _, part := range parts[1:] {
+		fArr := strings.SplitN(part, ":", 2)
+		f := outputFilter{
+			Name: strings.TrimSpace(fArr[0]),
+			Args: []*Expression{},
+		}
+		if !regexp.MustCompile(`^[a-zA-Z0-9_]+$`).MatchString(f.Name) {
+			return fmt.Errorf("Invalid filter '%s'", strings.TrimSpace(part))
+		}
+		if len(fArr) == 2 {
+			for _, arg := range splitOutsideQuotes(fArr[1], ',') {
+				e := &Expression{
+					Source: strings.TrimSpace(arg),
+				}
+				if err := e.Parse(); err != nil {
+					return fmt.Errorf("Invalid argument of filter %s: %w", f.Name, err)
+				}
+				f.Args = append(f.Args, e)
+			}
+		}
+		o.filters = append(o.filters, f)
+	}
+
+	return nil
+}
+
+// GetVariableRoot returns first part of the variable name, eg. 'page' for 'page.title', or empty string when
+// value is a literal
+func (o *Output) GetVariableRoot() string {
+	if o.value.root.Op != "variable" {
+		return ""
+	}
+	return splitVariablePath(o.value.root.Value.(string))[0]
+}
+
+// GetUnknownFilters returns names of filters that are not in the filters map
+func (o *Output) GetUnknownFilters(filters map[string]Filter) []string {
+	unknown := []string{}
+	for _, f := range o.filters {
+		if filters[f.Name] == nil {
+			unknown = append(unknown, f.Name)
+		}
+	}
+	return unknown
+}
+
+// GetUndefinedVariables returns names of variables used in the output that lookup func cannot find.  Value
+// that is passed through the default filter is allowed to be undefined.
+func (o *Output) GetUndefinedVariables(lookup func(string) (interface{}, bool)) []string {
+	undefined := []string{}
+	record := func(name string) (interface{}, bool) {
+		v, ok := lookup(name)
+		if !ok {
+			undefined = append(undefined, name)
+		}
+		return v, ok
+	}
+
+	hasDefault := false
+	for _, f := range o.filters {
+		if f.Name == "default" {
+			hasDefault = true
+		}
+	}
+	if !hasDefault {
+		o.value.Evaluate(record)
+	}
+	for _, f := range o.filters {
+		for _, arg := range f.Args {
+			arg.Evaluate(record)
+		}
+	}
+
+	return undefined
+}
+
+// Render returns value with filters applied.  Filters that are not found are skipped.
+func (o *Output) Render(lookup func(string) (interface{}, bool), filters map[string]Filter) (string, error) {
+	v := o.value.Evaluate(lookup)
+	for _, f := range o.filters {
+		fn := filters[f.Name]
+		if fn == nil {
+			continue
+		}
+		args := []interface{}{}
+		for _, arg := range f.Args {
+			args = append(args, arg.Evaluate(lookup))
+		}
+		var err error
+		v, err = fn(v, args)
+		if err != nil {
+			return "", fmt.Errorf("Error with filter %s: %w", f.Name, err)
+		}
+	}
+	return valueToString(v), nil
+}
+
+func splitOutsideQuotes(s string, sep rune) []string {
+	parts := []string{}
+	var quote rune
+	current := []rune{}
+	for _, ch := range s {
+		if quote != 0 {
+			if ch == quote {
+				quote = 0
+			}
+			current = append(current, ch)
+			continue
+		}
+		if ch == '"' || ch == '\'' {
+			quote = ch
+		}
+		if ch == sep {
+			parts = append(parts, string(current))
+			current = []rune{}
+			continue
+		}
+		current = append(current, ch)
+	}
+	return append(parts, string(current))
+}
+
+// AddFilter registers a custom filter that can be used in output tags.  It overrides a built-in filter with
+// the same name.
+func (g *Generator) AddFilter(name string, f Filter) {
+	if g.Filters == nil {
+		g.Filters = map[string]Filter{}
+	}
+	g.Filters[name] = f
+}
+
+func (g *Generator) getFilters(w *Website) map[string]Filter {
+	filters := g.getDefaultFilters(w)
+	for name, f := range g.Filters {
+		filters[name] = f
+	}
+	return filters
+}
+
+func (g *Generator) getDefaultFilters(w *Website) map[string]Filter {
+	baseurl := ""
+	siteUrl := ""
+	if w.Config != nil {
+		baseurl = strings.Trim(w.Config.Baseurl, "/")
+		siteUrl = strings.TrimRight(w.Config.Url, "/")
+	}
+	relativeUrl := func(s string) string {
+		if strings.Contains(s, "://") {
+			return s
+		}
+		p := "/" + strings.TrimLeft(s, "/")
+		if baseurl != "" {
+			p = "/" + baseurl + p
+		}
+		return p
+	}
+
+	return map[string]Filter{
+		"upcase": func(input interface{}, args []interface{}) (interface{}, error) {
+			return strings.ToUpper(valueToString(input)), nil
+		},
+		"downcase": func(input interface{}, args []interface{}) (interface{}, error) {
+			return strings.ToLower(valueToString(input)), nil
+		},
+		"capitalize": func(input interface{}, args []interface{}) (interface{}, error) {
+			r := []rune(valueToString(input))
+			if len(r) == 0 {
+				return "", nil
+			}
+			return strings.ToUpper(string(r[0])) + string(r[1:]), nil
+		},
+		"strip": func(input interface{}, args []interface{}) (interface{}, error) {
+			return strings.TrimSpace(valueToString(input)), nil
+		},
+		"strip_newlines": func(input interface{}, args []interface{}) (interface{}, error) {
+			return strings.NewReplacer("\r", "", "\n", "").Replace(valueToString(input)), nil
+		},
+		"strip_html": func(input interface{}, args []interface{}) (interface{}, error) {
+			return stripHtml(valueToString(input)), nil
+		},
+		"append": func(input interface{}, args []interface{}) (interface{}, error) {
+			return valueToString(input) + valueToString(getFilterArg(args, 0, "")), nil
+		},
+		"prepend": func(input interface{}, args []interface{}) (interface{}, error) {
+			return valueToString(getFilterArg(args, 0, "")) + valueToString(input), nil
+		},
+		"replace": func(input interface{}, args []interface{}) (interface{}, error) {
+			return strings.ReplaceAll(valueToString(input), valueToString(getFilterArg(args, 0, "")), valueToString(getFilterArg(args, 1, ""))), nil
+		},
+		"truncate": func(input interface{}, args []interface{}) (interface{}, error) {
+			length, err := getFilterIntArg(args, 0, 50)
+			if err != nil {
+				return nil, err
+			}
+			ellipsis := []rune(valueToString(getFilterArg(args, 1, "...")))
+			r := []rune(valueToString(input))
+			if len(r) <= length {
+				return string(r), nil
+			}
+			l := length - len(ellipsis)
+			if l < 0 {
+				l = 0
+			}
+			return string(r[:l]) + string(ellipsis), nil
+		},
+		"truncatewords": func(input interface{}, args []interface{}) (interface{}, error) {
+			length, err := getFilterIntArg(args, 0, 15)
+			if err != nil {
+				return nil, err
+			}
+			if length < 1 {
+				length = 1
+			}
+			words := strings.Fields(valueToString(input))
+			if len(words) <= length {
+				return strings.Join(words, " "), nil
+			}
+			return strings.Join(words[:length], " ") + valueToString(getFilterArg(args, 1, "...")), nil
+		},
+		"escape": func(input interface{}, args []interface{}) (interface{}, error) {
+			return html.EscapeString(valueToString(input)), nil
+		},
+		"escape_once": func(input interface{}, args []interface{}) (interface{}, error) {
+			return html.EscapeString(html.UnescapeString(valueToString(input))), nil
+		},
+		"xml_escape": func(input interface{}, args []interface{}) (interface{}, error) {
+			return html.EscapeString(valueToString(input)), nil
+		},
+		"url_encode": func(input interface{}, args []interface{}) (interface{}, error) {
+			return url.QueryEscape(valueToString(input)), nil
+		},
+		"slugify": func(input interface{}, args []interface{}) (interface{}, error) {
+			return g.slugify(valueToString(input)), nil
+		},
+		"default": func(input interface{}, args []interface{}) (interface{}, error) {
+			if !isTruthy(input) {
+				return getFilterArg(args, 0, ""), nil
+			}
+			return input, nil
+		},
+		"join": func(input interface{}, args []interface{}) (interface{}, error) {
+			items := []string{}
+			for _, item := range valueToList(input) {
+				items = append(items, valueToString(item))
+			}
+			return strings.Join(items, valueToString(getFilterArg(args, 0, " "))), nil
+		},
+		"split": func(input interface{}, args []interface{}) (interface{}, error) {
+			items := []interface{}{}
+			for _, item := range strings.Split(valueToString(input), valueToString(getFilterArg(args, 0, " "))) {
+				items = append(items, item)
+			}
+			return items, nil
+		},
+		"size": func(input interface{}, args []interface{}) (interface{}, error) {
+			v, _ := getPathValue(input, []string{"size"})
+			if v == nil {
+				return 0, nil
+			}
+			return v, nil
+		},
+		"first": func(input interface{}, args []interface{}) (interface{}, error) {
+			v, _ := getPathValue(input, []string{"first"})
+			return v, nil
+		},
+		"last": func(input interface{}, args []interface{}) (interface{}, error) {
+			v, _ := getPathValue(input, []string{"last"})
+			return v, nil
+		},
+		"markdownify": func(input interface{}, args []interface{}) (interface{}, error) {
+			return g.mdToHtml(valueToString(input)), nil
+		},
+		"relative_url": func(input interface{}, args []interface{}) (interface{}, error) {
+			return relativeUrl(valueToString(input)), nil
+		},
+		"absolute_url": func(input interface{}, args []interface{}) (interface{}, error) {
+			s := valueToString(input)
+			if strings.Contains(s, "://") {
+				return s, nil
+			}
+			return siteUrl + relativeUrl(s), nil
+		},
+		"date": func(input interface{}, args []interface{}) (interface{}, error) {
+			t, ok := valueToTime(input)
+			if !ok {
+				return input, nil
+			}
+			return formatDate(t, valueToString(getFilterArg(args, 0, "%Y-%m-%d"))), nil
+		},
+		"date_to_xmlschema": func(input interface{}, args []interface{}) (interface{}, error) {
+			t, ok := valueToTime(input)
+			if !ok {
+				return input, nil
+			}
+			return t.Format(time.RFC3339), nil
+		},
+		"date_to_rfc822": func(input interface{}, args []interface{}) (interface{}, error) {
+			t, ok := valueToTime(input)
+			if !ok {
+				return input, nil
+			}
+			return t.Format(time.RFC1123Z), nil
+		},
+	}
+}
+
+func getFilterArg(args []interface{}, i int, defaultValue interface{}) interface{} {
+	if i >= len(args) {
+		return defaultValue
+	}
+	return args[i]
+}
+
+func getFilterIntArg(args []interface{}, i int, defaultValue int) (int, error) {
+	if i >= len(args) {
+		return defaultValue, nil
+	}
+	f, ok := valueToFloat(args[i])
+	if !ok {
+		return 0, fmt.Errorf("Argument %d must be a number", i+1)
+	}
+	return int(f), nil
+}
+
+func stripHtml(s string) string {
+	re := regexp.MustCompile(`(?s)<script.*?</script>|<style.*?</style>|<!--.*?-->|<[^>]*>`)
+	return re.ReplaceAllString(s, "")
+}
+
+func valueToTime(v interface{}) (time.Time, bool) {
+	switch val := v.(type) {
+	case time.Time:
+		return val, true
+	case string:
+		if val == "now" || val == "today" {
+			return time.Now(), true
+		}
+		t, err := parseDate(val)
+		if err != nil {
+			return time.Time{}, false
+		}
+		return t, true
+	case int:
+		return time.Unix(int64(val), 0), true
+	}
+	return time.Time{}, false
+}
+
+// formatDate formats time using strftime directives, eg. %Y-%m-%d
+func formatDate(t time.Time, format string) string {
+	directives := map[byte]string{
+		'Y': "2006",
+		'y': "06",
+		'm': "01",
+		'd': "02",
+		'H': "15",
+		'I': "03",
+		'M': "04",
+		'S': "05",
+		'p': "PM",
+		'B': "January",
+		'b': "Jan",
+		'A': "Monday",
+		'a': "Mon",
+		'Z': "MST",
+		'z': "-0700",
+	}
+
+	// format is written byte by byte, so that multibyte characters in it are kept, eg. "%d de março"
+	var out strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i == len(format)-1 {
+			out.WriteByte(format[i])
+			continue
+		}
+		i++
+		switch format[i] {
+		case '%':
+			out.WriteByte('%')
+		case 'e':
+			out.WriteString(strconv.Itoa(t.Day()))
+		case 'j':
+			out.WriteString(fmt.Sprintf("%03d", t.YearDay()))
+		default:
+			if layout, ok := directives[format[i]]; ok {
+				out.WriteString(t.Format(layout))
+			} else {
+				out.WriteByte('%')
+				out.WriteByte(format[i])
+			}
+		}
+	}
+	return out.String()
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOutputFilters(t *testing.T) {
+	g := &Generator{}
+	w := &Website{
+		Config: &Config{
+			Url:     "http://example.com",
+			Baseurl: "blog",
+		},
+	}
+	g.AddFilter("shout", func(input interface{}, args []interface{}) (interface{}, error) {
+		return valueToString(input) + "!", nil
+	})
+	filters := g.getFilters(w)
+
+	vars := map[string]interface{}{
+		"page": map[string]interface{}{
+			"title": "Hello World",
+			"html":  "<p>Some <b>bold</b> text</p>",
+			"tags":  []interface{}{"go", "web"},
+			"date":  "2022-01-05 21:20:33 +0100",
+			"empty": "",
+			"url":   "/about/",
+		},
+	}
+	lookup := func(name string) (interface{}, bool) {
+		return getPathValue(vars, splitVariablePath(name))
+	}
+
+	for source, expected := range map[string]string{
+		`page.title | upcase`:                                    "HELLO WORLD",
+		`page.title | downcase | capitalize`:                     "Hello world",
+		`page.title | truncate: 8`:                               "Hello...",
+		`page.title | truncate: 8, "~"`:                          "Hello W~",
+		`page.title | truncatewords: 1`:                          "Hello...",
+		`page.html | strip_html`:                                 "Some bold text",
+		`page.html | escape`:                                     "&lt;p&gt;Some &lt;b&gt;bold&lt;/b&gt; text&lt;/p&gt;",
+		`"&lt;a & b" | escape_once`:                              "&lt;a &amp; b",
+		`"a&b" | xml_escape`:                                     "a&amp;b",
+		`"a b&c" | url_encode`:                                   "a+b%26c",
+		`page.title | slugify`:                                   "hello-world",
+		`page.empty | default: "none"`:                           "none",
+		`page.missing | default: page.title`:                     "Hello World",
+		`page.tags | join: ", "`:                                 "go, web",
+		`page.tags | size`:                                       "2",
+		`page.title | size`:                                      "11",
+		`page.tags | first`:                                      "go",
+		`"**bold**" | markdownify | strip`:                       "<p><strong>bold</strong></p>",
+		`page.url | relative_url`:                                "/blog/about/",
+		`page.url | absolute_url`:                                "http://example.com/blog/about/",
+		`page.date | date: "%Y-%m-%d %H:%M"`:                     "2022-01-05 21:20",
+		`page.date | date: "%B %e, %Y (%a, %j)"`:                 "January 5, 2022 (Wed, 005)",
+		`page.date | date_to_xmlschema`:                          "2022-01-05T21:20:33+01:00",
+		`page.title | shout`:                                     "Hello World!",
+		`page.title | unknown | upcase`:                          "HELLO WORLD",
+		`"a,b" | split: "," | join: "-" | append: "!"`:           "a-b!",
+		`page.title | replace: "World", "There" | prepend: "> "`: "> Hello There",
+	} {
+		out := &Output{Source: source}
+		if err := out.Parse(); err != nil {
+			t.Fatalf("Parse returned error for %s: %s", source, err.Error())
+		}
+		s, err := out.Render(lookup, filters)
+		if err != nil || s != expected {
+			t.Fatalf("Render returned %s instead of %s for %s", s, expected, source)
+		}
+	}
+
+	out := &Output{Source: `page.title | truncate: "x"`}
+	out.Parse()
+	if _, err := out.Render(lookup, filters); err == nil {
+		t.Fatalf("Render failed to return error on invalid filter argument")
+	}
+
+	for _, source := range []string{`page.title ==`, `page.title | `, `page.title | truncate: (`} {
+		out := &Output{Source: source}
+		if err := out.Parse(); err == nil {
+			t.Fatalf("Parse failed to return error for %s", source)
+		}
+	}
+
+	if formatDate(time.Date(2023, 12, 1, 15, 4, 0, 0, time.UTC), "%d/%m/%y %I%p %%") != "01/12/23 03PM %" {
+		t.Fatalf("formatDate returned invalid string")
+	}
+	if s := formatDate(time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC), "%d de março %ó"); s != "01 de março %ó" {
+		t.Fatalf("formatDate returned %s instead of 01 de março %%ó", s)
+	}
+}
+
+func TestProcessOutputTags(t *testing.T) {
+	n := &Node{
+		Type: "root",
+		Values: map[string]interface{}{
+			"page": map[string]interface{}{"title": "Home"},
+		},
+	}
+	n.SetFromString(`{{ page.title | upcase }} {{ unknown.var }} {{ "x" | upcase }} {% for i in (1..2) %}{{ i | append: "." }}{% endfor %}`, '{', '}', '%')
+	n.ProcessForTags("{%", "%}", &Website{}, &Generator{})
+	if err := n.ProcessOutputTags((&Generator{}).getFilters(&Website{})); err != nil {
+		t.Fatalf("ProcessOutputTags returned error: %s", err.Error())
+	}
+	s := n.GetRaw("{%", "%}")
+	if s != "HOME {{ unknown.var }} X 1.2." {
+		t.Fatalf("ProcessOutputTags returned invalid string: %s", s)
+	}
+}
@@ -1,22 +1,35 @@
 package main
 
 import (
-	"errors"
 	"fmt"
 	"github.com/gomarkdown/markdown"
 	"github.com/gomarkdown/markdown/html"
 	"github.com/gomarkdown/markdown/parser"
+	"io"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
 	"reflect"
 	"regexp"
 	"strings"
+	"time"
 )
 
+// layoutContentPlaceholder is put in place of content in a layout while the layout tags are processed
+const layoutContentPlaceholder = "<!--- SPIDEY:CONTENT -->"
+
 type Generator struct {
 	DestinationPath string
-
-	cachedSiteVariables map[string]string
+	Filters         map[string]Filter
+	// Strict makes undefined variables, unknown filters and unbalanced tags fail the generation
+	Strict bool
+
+	cachedSiteVariables map[string]interface{}
+	cachedFilters       map[string]Filter
+	taxonomyPages       []*Page
+	paginatedPages      []*Page
+	problems            []error
 }
 
 func (g *Generator) Generate(w *Website) error {
@@ -25,17 +38,65 @@ func (g *Generator) Generate(w *Website) error {
 		return err
 	}
 
-	g.getSiteVariables(w.Config)
+	// unbalanced tags are reported first and on their own, as pages with them cannot be rendered reliably to
+	// find undefined variables and unknown filters
+	g.problems = []error{}
+	if g.isStrict(w) {
+		g.checkTags(w)
+		if err := g.getProblemsError(); err != nil {
+			return err
+		}
+	}
+
+	if err := g.setUrls(w); err != nil {
+		return err
+	}
+
+	// excerpts are processed like contents, so they need site variables, which are read again to include them
+	g.getSiteVariables(w)
+	g.cachedFilters = g.getFilters(w)
+	if err := g.setExcerpts(w); err != nil {
+		return err
+	}
+	g.getSiteVariables(w)
+
+	if err := g.setExtraPages(w); err != nil {
+		return err
+	}
 
 	if err := g.generatePosts(w); err != nil {
 		return err
 	}
 
+	if err := g.copyStaticFiles(w); err != nil {
+		return err
+	}
+
 	if err := g.generatePages(w); err != nil {
 		return err
 	}
 
-	return nil
+	if err := g.generateExtraPages(g.taxonomyPages, w); err != nil {
+		return err
+	}
+
+	if err := g.generateExtraPages(g.paginatedPages, w); err != nil {
+		return err
+	}
+
+	if err := g.generateFeeds(w); err != nil {
+		return err
+	}
+
+	if err := g.generateSitemap(w); err != nil {
+		return err
+	}
+
+	if err := g.generateRobots(w); err != nil {
+		return err
+	}
+
+	return g.getProblemsError()
 }
 
 func (g *Generator) checkIfDestinationPathEmpty() error {
@@ -64,8 +125,119 @@ func (g *Generator) checkIfDestinationPathEmpty() error {
 	return nil
 }
 
-func (g *Generator) getSiteVariables(c *Config) {
-	g.cachedSiteVariables = g.getObjVariablesFromYamlTag(c)
+func (g *Generator) CleanDestinationPath() error {
+	entries, err := os.ReadDir(g.DestinationPath)
+	if err != nil {
+		return fmt.Errorf("Error reading destination path of %s: %w", g.DestinationPath, err)
+	}
+
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		p := filepath.Join(g.DestinationPath, e.Name())
+		if err := os.RemoveAll(p); err != nil {
+			return fmt.Errorf("Error removing %s: %w", p, err)
+		}
+	}
+
+	return nil
+}
+
+func (g *Generator) getSiteVariables(w *Website) {
+	vars := map[string]interface{}{}
+	if w.Config != nil {
+		for k, v := range w.Config.Values {
+			vars[k] = v
+		}
+		for k, v := range g.getObjVariablesFromYamlTag(w.Config) {
+			vars[k] = v
+		}
+	}
+
+	posts := []interface{}{}
+	categories := map[string]interface{}{}
+	tags := map[string]interface{}{}
+	for _, name := range w.PostsNames {
+		post := w.Posts[name]
+		postVars := g.getPageVariables(post)
+		posts = append(posts, postVars)
+		for _, c := range post.GetCategories() {
+			list, _ := categories[c].([]interface{})
+			categories[c] = append(list, postVars)
+		}
+		for _, t := range post.GetTags() {
+			list, _ := tags[t].([]interface{})
+			tags[t] = append(list, postVars)
+		}
+	}
+
+	pages := []interface{}{}
+	for _, name := range w.PageNames {
+		pages = append(pages, g.getPageVariables(w.Pages[name]))
+	}
+
+	vars["posts"] = posts
+	vars["pages"] = pages
+	vars["categories"] = categories
+	vars["tags"] = tags
+	if w.Data != nil {
+		vars["data"] = w.Data
+	}
+
+	g.cachedSiteVariables = vars
+}
+
+// getSiteVariablesFor returns cached site variables, getting them first when they have not been yet
+func (g *Generator) getSiteVariablesFor(w *Website) map[string]interface{} {
+	if g.cachedSiteVariables == nil {
+		g.getSiteVariables(w)
+	}
+	return g.cachedSiteVariables
+}
+
+// getPageVariables returns all front matter values, with fields from the Page struct overriding the ones that
+// are not empty
+func (g *Generator) getPageVariables(p *Page) map[string]interface{} {
+	vars := map[string]interface{}{}
+	for k, v := range p.FrontMatter {
+		vars[k] = v
+	}
+	for k, v := range g.getObjVariablesFromYamlTag(p) {
+		if _, ok := vars[k]; !ok || v != "" {
+			vars[k] = v
+		}
+	}
+
+	categories := []interface{}{}
+	for _, c := range p.GetCategories() {
+		categories = append(categories, c)
+	}
+	tags := []interface{}{}
+	for _, t := range p.GetTags() {
+		tags = append(tags, t)
+	}
+	vars["categories"] = categories
+	vars["tags"] = tags
+	vars["excerpt"] = p.Excerpt
+	vars["excerpt_text"] = p.ExcerptText
+
+	// posts dated only by their filename get the date from it so that they can be sorted and formatted
+	if d, _ := vars["date"].(string); d == "" && !p.Time.IsZero() {
+		vars["date"] = p.Time.Format(time.RFC3339)
+	}
+
+	return vars
+}
+
+// getLayoutVariables returns front matter of the layout with its name
+func (g *Generator) getLayoutVariables(l *Layout) map[string]interface{} {
+	vars := map[string]interface{}{}
+	for k, v := range l.FrontMatter {
+		vars[k] = v
+	}
+	vars["name"] = l.Name
+	return vars
 }
 
 func (g *Generator) getObjVariablesFromYamlTag(obj interface{}) map[string]string {
@@ -81,7 +253,7 @@ func (g *Generator) getObjVariablesFromYamlTag(obj interface{}) map[string]strin
 			continue
 		}
 		tagVal := field.Tag.Get("yaml")
-		if tagVal == "" {
+		if tagVal == "" || tagVal == "-" {
 			continue
 		}
 		tagValArr := strings.Split(tagVal, ",")
@@ -97,22 +269,8 @@ func (g *Generator) generatePages(w *Website) error {
 			return fmt.Errorf("Error generating page %s HTML: %w", name, err)
 		}
 
-		pagePath := filepath.Join(g.DestinationPath, "index.html")
-		if name != "index" && name != "404" {
-			pageDir := filepath.Join(g.DestinationPath, name)
-			err := os.Mkdir(pageDir, 0750)
-			if err != nil {
-				return fmt.Errorf("Error creating page %s directory: %w", name, err)
-			}
-			pagePath = filepath.Join(pageDir, "index.html")
-		}
-		if name == "404" {
-			pagePath = filepath.Join(g.DestinationPath, "404.html")
-		}
-
-		err = os.WriteFile(pagePath, []byte(pageHtml), 0750)
-		if err != nil {
-			return fmt.Errorf("Error writing page html to %s: %w", pagePath, err)
+		if err := g.writeUrlFile(page.Url, pageHtml); err != nil {
+			return fmt.Errorf("Error writing page %s: %w", name, err)
 		}
 	}
 	return nil
@@ -125,105 +283,288 @@ func (g *Generator) generatePosts(w *Website) error {
 			return fmt.Errorf("Error generating post %s HTML: %w", name, err)
 		}
 
-		re := regexp.MustCompile(`^([0-9]{4})-([01][0-9])-([01][0-9])-([a-zA-Z0-9\_\-]+)$`)
-		if !re.MatchString(name) {
-			return fmt.Errorf("Post %s filename does not match regexp", name)
+		if err := g.writeUrlFile(post.Url, postHtml); err != nil {
+			return fmt.Errorf("Error writing post %s: %w", name, err)
 		}
-		nameArr := re.FindStringSubmatch(name)
+	}
+	return nil
+}
 
-		destPath := []string{}
-		re = regexp.MustCompile(`^[a-zA-Z0-9\_\- ]+$`)
-		if post.Categories != "" && re.MatchString(post.Categories) {
-			categoriesList := strings.Split(post.Categories, " ")
-			for _, c := range categoriesList {
-				if c != "" {
-					destPath = append(destPath, c)
-				}
-			}
-		} else {
-			destPath = append(destPath, "posts")
+// generateExtraPages renders pages that do not come from source files, eg. category or paginated pages
+func (g *Generator) generateExtraPages(pages []*Page, w *Website) error {
+	for _, page := range pages {
+		pageHtml, err := g.getPageHtml(page, w)
+		if err != nil {
+			return fmt.Errorf("Error generating %s HTML: %w", page.Name, err)
 		}
 
-		postDir := filepath.Join(destPath...)
-		postDir = filepath.Join(postDir, nameArr[1], nameArr[2], nameArr[3])
-		err = os.MkdirAll(filepath.Join(g.DestinationPath, postDir), 0750)
+		if err := g.writeUrlFile(page.Url, pageHtml); err != nil {
+			return fmt.Errorf("Error writing %s: %w", page.Name, err)
+		}
+	}
+	return nil
+}
+
+func (g *Generator) writeUrlFile(url string, h string) error {
+	p := filepath.Join(g.DestinationPath, g.getUrlFilePath(url))
+
+	err := os.MkdirAll(filepath.Dir(p), 0750)
+	if err != nil {
+		return fmt.Errorf("Error creating directory %s: %w", filepath.Dir(p), err)
+	}
+
+	err = os.WriteFile(p, []byte(h), 0750)
+	if err != nil {
+		return fmt.Errorf("Error writing html to %s: %w", p, err)
+	}
+	return nil
+}
+
+func (g *Generator) copyStaticFiles(w *Website) error {
+	sourcePath, err := filepath.Abs(w.SourcePath)
+	if err != nil {
+		return fmt.Errorf("Error getting absolute path of %s: %w", w.SourcePath, err)
+	}
+	destinationPath, err := filepath.Abs(g.DestinationPath)
+	if err != nil {
+		return fmt.Errorf("Error getting absolute path of %s: %w", g.DestinationPath, err)
+	}
+
+	return filepath.WalkDir(sourcePath, func(p string, d fs.DirEntry, err error) error {
 		if err != nil {
-			return fmt.Errorf("Error creating post %s directory %s: %w", name, postDir, err)
+			return fmt.Errorf("Error walking %s: %w", p, err)
+		}
+		if p == sourcePath {
+			return nil
+		}
+		if p == destinationPath {
+			return filepath.SkipDir
 		}
 
-		postPath := filepath.Join(postDir, "index.html")
-		w.Posts[name].Url = "/" + postPath
+		rel, err := filepath.Rel(sourcePath, p)
+		if err != nil {
+			return fmt.Errorf("Error getting relative path of %s: %w", p, err)
+		}
 
-		err = os.WriteFile(filepath.Join(g.DestinationPath, postPath), []byte(postHtml), 0750)
+		if !g.isStaticFile(rel, w) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		fileInfo, err := d.Info()
 		if err != nil {
-			return fmt.Errorf("Error writing post html to %s: %w", postPath, err)
+			return fmt.Errorf("Error getting file info for %s: %w", p, err)
+		}
+
+		destPath := filepath.Join(g.DestinationPath, rel)
+		if d.IsDir() {
+			if err := os.MkdirAll(destPath, fileInfo.Mode().Perm()); err != nil {
+				return fmt.Errorf("Error creating directory %s: %w", destPath, err)
+			}
+			return nil
+		}
+
+		if !fileInfo.Mode().IsRegular() {
+			return nil
+		}
+
+		if err := g.copyFile(p, destPath, fileInfo.Mode().Perm()); err != nil {
+			return fmt.Errorf("Error copying %s: %w", rel, err)
+		}
+		return nil
+	})
+}
+
+func (g *Generator) isStaticFile(rel string, w *Website) bool {
+	rel = filepath.ToSlash(rel)
+
+	for _, pattern := range w.Config.Exclude {
+		if g.matchesPath(pattern, rel) {
+			return false
 		}
 	}
-	return nil
+
+	included := false
+	for _, pattern := range w.Config.Include {
+		if g.matchesPath(pattern, rel) {
+			included = true
+			break
+		}
+	}
+
+	if !included {
+		for _, name := range strings.Split(rel, "/") {
+			if strings.HasPrefix(name, "_") || strings.HasPrefix(name, ".") {
+				return false
+			}
+		}
+	}
+
+	// pages in the root of the source directory are generated, not copied
+	if !strings.Contains(rel, "/") {
+		ext := filepath.Ext(rel)
+		if (ext == ".markdown" || ext == ".html") && w.Pages[strings.TrimSuffix(rel, ext)] != nil {
+			return false
+		}
+	}
+
+	return true
 }
 
-func (g *Generator) getPageHtml(p *Page, w *Website) (string, error) {
-	if w.Layouts[p.Layout] == nil {
-		return "", fmt.Errorf("Layout %s does not exist", p.Layout)
+func (g *Generator) matchesPath(pattern string, rel string) bool {
+	pattern = strings.Trim(filepath.ToSlash(pattern), "/")
+	if pattern == "" {
+		return false
+	}
+	if rel == pattern || strings.HasPrefix(rel, pattern+"/") {
+		return true
+	}
+	if ok, _ := path.Match(pattern, rel); ok {
+		return true
 	}
+	if ok, _ := path.Match(pattern, path.Base(rel)); ok {
+		return true
+	}
+	return false
+}
 
-	pageHtml := w.Layouts[p.Layout].Body
+// hasSourceFile returns true when file with url path exists in the source directory, eg. robots.txt
+func (g *Generator) hasSourceFile(url string, w *Website) bool {
+	_, err := os.Stat(filepath.Join(w.SourcePath, filepath.FromSlash(url)))
+	return err == nil
+}
 
-	contentHtml := ""
-	if p.ContentType == "html" {
-		contentHtml = p.Body
-	} else if p.ContentType == "markdown" {
-		contentHtml = g.mdToHtml(p.Body)
+func (g *Generator) copyFile(src string, dst string, mode os.FileMode) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("Error opening file %s: %w", src, err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("Error creating file %s: %w", dst, err)
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("Error writing file %s: %w", dst, err)
+	}
+
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("Error closing file %s: %w", dst, err)
+	}
+
+	return os.Chmod(dst, mode)
+}
+
+func (g *Generator) getPageHtml(p *Page, w *Website) (string, error) {
+	layouts, err := g.getPageLayouts(p, w)
+	if err != nil {
+		return "", err
 	}
 
-	re := regexp.MustCompile(`\{\{[ ]*content[ ]*\}\}`)
-	for _, cont := range re.FindAllStringSubmatch(pageHtml, -1) {
-		pageHtml = strings.ReplaceAll(pageHtml, cont[0], contentHtml)
+	pageHtml, err := g.getContentHtml(p, w, layouts[0])
+	if err != nil {
+		return "", err
 	}
 
-	// TODO: Fix it to actually replace until necessary, with either limit or infinite
-	// cycle detection
-	var err error
-	for i := 0; i < 10; i++ {
-		pageHtml, err = g.replaceIncludes(pageHtml, w)
+	// every layout is rendered with its own variables and then the content is put in it, so that tags in the
+	// content are not processed again
+	re := regexp.MustCompile(`\{\{(@[0-9]+:[0-9]+)?[ ]*content[ ]*\}\}`)
+	for _, layout := range layouts {
+		layoutHtml := layout.Body
+		if layout.Path != "" {
+			layoutHtml = annotateTagPositions(layoutHtml, layout.BodyLine)
+		}
+		layoutHtml = re.ReplaceAllString(layoutHtml, layoutContentPlaceholder)
+
+		layoutHtml, err = g.processTags(layoutHtml, layout.Path, w, p, layout)
 		if err != nil {
-			return "", fmt.Errorf("Error replacing includes in page %s: %w", p.Name, err)
+			return "", fmt.Errorf("Error processing tags in layout %s of page %s: %w", layout.Name, p.Name, err)
 		}
+
+		pageHtml = strings.ReplaceAll(layoutHtml, layoutContentPlaceholder, pageHtml)
 	}
 
-	pageHtml, err = g.processTags(pageHtml, w, p)
 	pageHtml = g.addBaseUrl(pageHtml, w)
 
 	return pageHtml, nil
 }
 
-func (g *Generator) processTags(s string, w *Website, p *Page) (string, error) {
-	s, err := g.replaceOnTree(s, w, p)
+// getPageLayouts returns layout of the page followed by layouts it is nested in
+func (g *Generator) getPageLayouts(p *Page, w *Website) ([]*Layout, error) {
+	if w.Layouts[p.Layout] == nil {
+		return nil, fmt.Errorf("Layout %s does not exist", p.Layout)
+	}
+
+	layouts := []*Layout{w.Layouts[p.Layout]}
+	names := []string{p.Layout}
+	for {
+		layout := layouts[len(layouts)-1]
+		if layout.Layout == "" {
+			break
+		}
+
+		for _, name := range names {
+			if name == layout.Layout {
+				return nil, fmt.Errorf("Layout cycle detected: %s -> %s", strings.Join(names, " -> "), layout.Layout)
+			}
+		}
+		if w.Layouts[layout.Layout] == nil {
+			return nil, fmt.Errorf("Layout %s used by layout %s does not exist", layout.Layout, layout.Name)
+		}
+
+		layouts = append(layouts, w.Layouts[layout.Layout])
+		names = append(names, layout.Layout)
+	}
+
+	return layouts, nil
+}
+
+// getContentHtml returns HTML of the page content with tags processed, without the layout
+func (g *Generator) getContentHtml(p *Page, w *Website, layout *Layout) (string, error) {
+	body := p.Body
+	if p.Path != "" {
+		body = annotateTagPositions(body, p.BodyLine)
+	}
+
+	contentHtml := ""
+	if p.ContentType == "html" {
+		contentHtml = body
+	} else if p.ContentType == "markdown" {
+		contentHtml = g.mdToHtml(body)
+	}
+
+	contentHtml, err := g.processTags(contentHtml, p.Path, w, p, layout)
 	if err != nil {
-		return "", fmt.Errorf("Error replacing ifs and fors: %w", err)
+		return "", fmt.Errorf("Error processing tags in page %s: %w", p.Name, err)
 	}
-	s, err = g.replaceVariables(s, w, p)
+
+	return contentHtml, nil
+}
+
+// processTags processes tags in s, which comes from the file at path, with positions of tags annotated
+// if path is not empty
+func (g *Generator) processTags(s string, path string, w *Website, p *Page, layout *Layout) (string, error) {
+	s, err := g.replaceOnTree(s, path, w, p, layout)
 	if err != nil {
-		return "", fmt.Errorf("Error replacing vars: %w", err)
+		return "", fmt.Errorf("Error replacing ifs and fors: %w", err)
 	}
 	return s, nil
 }
 
 func (g *Generator) mdToHtml(md string) string {
+	// tags are replaced with placeholders so that markdown does not change them, eg. quotes or pipes
 	replaced := map[string]string{}
-	re := regexp.MustCompile(`\{%[ ]*include[ ]*([a-zA-Z0-9\-\_]+)\.(html|markdown)[ ]*%\}`)
-	for _, incl := range re.FindAllStringSubmatch(md, -1) {
-		replacement := fmt.Sprintf("<!--- TMPTAG:%s -->", incl[0])
-		replaced[incl[0]] = replacement
-		md = strings.ReplaceAll(md, incl[0], replacement)
-	}
-
-	re = regexp.MustCompile(`\{%[ ]*(endraw|raw)[ ]*%\}`)
-	for _, tags := range re.FindAllStringSubmatch(md, -1) {
-		replacement := fmt.Sprintf("<!--- TMPTAG:%s -->", tags[0])
-		replaced[tags[0]] = replacement
-		md = strings.ReplaceAll(md, tags[0], replacement)
-	}
+	re := regexp.MustCompile(`(?s)\{%.*?%\}|\{\{.*?\}\}`)
+	md = re.ReplaceAllStringFunc(md, func(tag string) string {
+		replacement := fmt.Sprintf("<!--- TMPTAG:%d -->", len(replaced))
+		replaced[replacement] = tag
+		return replacement
+	})
 
 	extensions := parser.CommonExtensions | parser.NoEmptyLineBeforeBlock
 	p := parser.NewWithExtensions(extensions)
@@ -235,41 +576,12 @@ func (g *Generator) mdToHtml(md string) string {
 
 	h := string(markdown.Render(doc, renderer))
 	for k, v := range replaced {
-		h = strings.ReplaceAll(h, v, k)
+		h = strings.ReplaceAll(h, k, v)
 	}
 
 	return h
 }
 
-func (g *Generator) replaceIncludes(h string, w *Website) (string, error) {
-	re := regexp.MustCompile(`\{%[ ]*include[ ]*([a-zA-Z0-9\-\_]+)\.(html|markdown)[ ]*%\}`)
-	for _, incl := range re.FindAllStringSubmatch(h, -1) {
-		inclName := incl[1]
-		if w.Includes[inclName] == nil {
-			return "", fmt.Errorf("Include %s does not exist", inclName)
-		}
-		h = strings.ReplaceAll(h, incl[0], w.Includes[inclName].Body)
-	}
-	return h, nil
-}
-
-func (g *Generator) replaceVariables(h string, w *Website, p *Page) (string, error) {
-	pageVars := g.getObjVariablesFromYamlTag(p)
-	re := regexp.MustCompile(`\{\{[ ]*(site|page)\.([a-zA-Z0-9\-\_]+)[ ]*\}\}`)
-	for _, found := range re.FindAllStringSubmatch(h, -1) {
-		varType := found[1]
-		varName := found[2]
-		if varType == "page" {
-			h = strings.ReplaceAll(h, found[0], pageVars[varName])
-		} else if varType == "site" {
-			h = strings.ReplaceAll(h, found[0], g.cachedSiteVariables[varName])
-		} else {
-			return "", errors.New(fmt.Sprintf("Invalid variable type %s", varType))
-		}
-	}
-	return h, nil
-}
-
 func (g *Generator) addBaseUrl(h string, w *Website) string {
 	re := regexp.MustCompile(`href="/`)
 	url := w.Config.Url
@@ -282,19 +594,43 @@ func (g *Generator) addBaseUrl(h string, w *Website) string {
 	return h
 }
 
-func (g *Generator) replaceOnTree(h string, w *Website, p *Page) (string, error) {
+func (g *Generator) replaceOnTree(h string, path string, w *Website, p *Page, layout *Layout) (string, error) {
+	pageVars := g.getPageVariables(p)
 	tree := &Node{
-		Type: "root",
+		Type:   "root",
+		Source: path,
+		Values: map[string]interface{}{
+			"site": g.cachedSiteVariables,
+			"page": pageVars,
+		},
+	}
+	if p.Paginator != nil {
+		tree.Values["paginator"] = p.Paginator
+	}
+	if layout != nil {
+		tree.Values["layout"] = g.getLayoutVariables(layout)
 	}
 	tree.SetFromString(h, '{', '}', '%')
 	tree.ProcessRawTags("{%", "%}")
-	tree.ProcessForTags("{%", "%}", w, g)
-
-	pageVars := g.getObjVariablesFromYamlTag(p)
-	tree.ProcessIfTags(g.cachedSiteVariables, pageVars)
-	tree.ProcessPostVars()
+	if err := tree.ProcessForTags("{%", "%}", w, g); err != nil {
+		return "", err
+	}
+	if err := tree.ProcessIfTags(g.cachedSiteVariables, pageVars); err != nil {
+		return "", err
+	}
+	if err := tree.ProcessIncludeTags("{%", "%}", w, g, g.cachedSiteVariables, pageVars); err != nil {
+		return "", err
+	}
+	if g.isStrict(w) {
+		for _, problem := range tree.CheckOutputTags(g.cachedFilters) {
+			g.addProblem(p, problem)
+		}
+	}
+	if err := tree.ProcessOutputTags(g.cachedFilters); err != nil {
+		return "", err
+	}
 
-	h = tree.GetRaw("", "")
+	h = stripTagPositions(tree.GetRaw("", ""))
 
 	return h, nil
 }
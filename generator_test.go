@@ -0,0 +1,367 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCopyStaticFiles(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+	os.MkdirAll(filepath.Join(src, "assets", "css"), 0750)
+	os.MkdirAll(filepath.Join(src, "_layouts"), 0750)
+	os.MkdirAll(filepath.Join(src, "drafts"), 0750)
+	os.WriteFile(filepath.Join(src, "index.markdown"), []byte("Index!"), 0640)
+	os.WriteFile(filepath.Join(src, "favicon.ico"), []byte("Icon!"), 0640)
+	os.WriteFile(filepath.Join(src, ".htaccess"), []byte("Htaccess!"), 0640)
+	os.WriteFile(filepath.Join(src, ".hidden"), []byte("Hidden!"), 0640)
+	os.WriteFile(filepath.Join(src, "run.sh"), []byte("Script!"), 0750)
+	os.WriteFile(filepath.Join(src, "assets", "css", "style.css"), []byte("Style!"), 0640)
+	os.WriteFile(filepath.Join(src, "_layouts", "default.html"), []byte("Layout!"), 0640)
+	os.WriteFile(filepath.Join(src, "drafts", "draft.txt"), []byte("Draft!"), 0640)
+
+	w := &Website{
+		SourcePath: src,
+		Config: &Config{
+			Include: []string{".htaccess"},
+			Exclude: []string{"drafts", "*.sh"},
+		},
+		Pages: map[string]*Page{
+			"index": &Page{},
+		},
+	}
+	g := &Generator{
+		DestinationPath: dst,
+	}
+
+	if err := g.copyStaticFiles(w); err != nil {
+		t.Fatalf("copyStaticFiles returned error: %s", err.Error())
+	}
+
+	for _, p := range []string{"favicon.ico", ".htaccess", "assets/css/style.css"} {
+		if _, err := os.Stat(filepath.Join(dst, p)); err != nil {
+			t.Fatalf("copyStaticFiles failed to copy %s", p)
+		}
+	}
+	for _, p := range []string{"index.markdown", ".hidden", "run.sh", "_layouts", "drafts"} {
+		if _, err := os.Stat(filepath.Join(dst, p)); err == nil {
+			t.Fatalf("copyStaticFiles copied %s which should be skipped", p)
+		}
+	}
+}
+
+func TestGetPostUrl(t *testing.T) {
+	g := &Generator{}
+	w := &Website{
+		Config: &Config{},
+	}
+
+	for _, tc := range []struct {
+		name       string
+		post       *Page
+		configLink string
+		url        string
+	}{
+		{"2022-01-25-some-title", &Page{Categories: StringList{"cat1", "cat2"}}, "", "/cat1/cat2/2022/01/25/some-title/"},
+		{"2022-01-25-some-title", &Page{}, "", "/posts/2022/01/25/some-title/"},
+		{"2022-01-25-some-title", &Page{Categories: StringList{"cat1"}}, "date", "/cat1/2022/01/25/some-title.html"},
+		{"2022-01-25-some-title", &Page{Title: "Hello, World!"}, "/blog/:year/:slug/", "/blog/2022/hello-world/"},
+		{"2022-01-25-some-title", &Page{Permalink: "/custom.html"}, "/blog/:year/:slug/", "/custom.html"},
+		{"2022-01-25-some-title", &Page{Permalink: "/:title"}, "", "/some-title/"},
+	} {
+		w.Config.Permalink = tc.configLink
+		url, err := g.getPostUrl(tc.name, tc.post, w)
+		if err != nil || url != tc.url {
+			t.Fatalf("getPostUrl returned %s instead of %s", url, tc.url)
+		}
+	}
+
+	if _, err := g.getPostUrl("2022-1-1-x", &Page{}, w); err == nil {
+		t.Fatalf("getPostUrl failed to return error on invalid filename")
+	}
+	if _, err := g.getPostUrl("2022-01-01-x", &Page{Permalink: "/:unknown/"}, w); err == nil {
+		t.Fatalf("getPostUrl failed to return error on unknown placeholder")
+	}
+}
+
+func TestGetPageUrl(t *testing.T) {
+	g := &Generator{}
+	for _, tc := range []struct {
+		name string
+		page *Page
+		url  string
+	}{
+		{"index", &Page{}, "/"},
+		{"404", &Page{}, "/404.html"},
+		{"about", &Page{}, "/about/"},
+		{"about", &Page{Permalink: "/about-us.html"}, "/about-us.html"},
+	} {
+		url, err := g.getPageUrl(tc.name, tc.page)
+		if err != nil || url != tc.url {
+			t.Fatalf("getPageUrl returned %s instead of %s", url, tc.url)
+		}
+	}
+	if g.getUrlFilePath("/about/") != filepath.Join("about", "index.html") ||
+		g.getUrlFilePath("/") != "index.html" ||
+		g.getUrlFilePath("/a/b.html") != filepath.Join("a", "b.html") {
+		t.Fatalf("getUrlFilePath returned invalid path")
+	}
+}
+
+func TestSortPosts(t *testing.T) {
+	w := &Website{
+		PostsNames: []string{"2022-01-01-a", "2023-01-01-b", "2022-06-01-c", "2022-06-01-d"},
+		Posts: map[string]*Page{
+			"2022-01-01-a": &Page{Name: "2022-01-01-a"},
+			"2023-01-01-b": &Page{Name: "2023-01-01-b"},
+			"2022-06-01-c": &Page{Name: "2022-06-01-c", Date: "2022-06-01 10:00:00 +0000"},
+			"2022-06-01-d": &Page{Name: "2022-06-01-d", Date: "2022-06-01 09:00:00 +0000"},
+		},
+	}
+	for _, name := range w.PostsNames {
+		if err := w.Posts[name].SetTime(); err != nil {
+			t.Fatalf("SetTime returned error: %s", err.Error())
+		}
+	}
+	w.sortPosts()
+
+	for i, name := range []string{"2023-01-01-b", "2022-06-01-c", "2022-06-01-d", "2022-01-01-a"} {
+		if w.PostsNames[i] != name {
+			t.Fatalf("sortPosts failed to sort posts by date")
+		}
+	}
+
+	p := &Page{Date: "yesterday"}
+	if err := p.SetTime(); err == nil {
+		t.Fatalf("SetTime failed to return error on invalid date")
+	}
+}
+
+func TestSetFromFileTypeErrors(t *testing.T) {
+	dir := t.TempDir()
+	for content, expected := range map[string]string{
+		"---\ntitle: Title\nauthor:\n  - A\n  - B\npaginate: \"10\"\n---\nBody\n":    "page.markdown:6: Error setting page from YAML: line 5: cannot unmarshal !!str `10` into int",
+		"---\ntitle: Title\ncategories:\n  a: b\n---\nBody\n":                        "page.markdown:4: Error setting page from YAML: line 3: cannot unmarshal !!map into string",
+		"---\ntitle: Title\nauthor:\n  - A\n  - B\ndescription: {a: b}\n---\nBody\n": "",
+	} {
+		p := filepath.Join(dir, "page.markdown")
+		os.WriteFile(p, []byte(content), 0640)
+		err := (&Page{}).SetFromFile(p)
+		if expected == "" {
+			if err != nil {
+				t.Fatalf("SetFromFile returned error %s for %s", err.Error(), content)
+			}
+			continue
+		}
+		if err == nil || strings.TrimPrefix(err.Error(), dir+string(filepath.Separator)) != expected {
+			t.Fatalf("SetFromFile returned error %v instead of %s for %s", err, expected, content)
+		}
+	}
+}
+
+func TestGetPageVariables(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "page.markdown")
+	os.WriteFile(p, []byte("---\n"+
+		"layout: default\n"+
+		"title: Title\n"+
+		"hero_image: /img/hero.png\n"+
+		"featured: true\n"+
+		"order: 3\n"+
+		"authors:\n"+
+		"  - name: Author1\n"+
+		"  - name: Author2\n"+
+		"seo:\n"+
+		"  keywords: [a, b]\n"+
+		"tags: [go, yaml]\n"+
+		"description: [first, second]\n"+
+		"---\n"+
+		"Body\n"), 0640)
+
+	page := &Page{}
+	if err := page.SetFromFile(p); err != nil {
+		t.Fatalf("SetFromFile returned error: %s", err.Error())
+	}
+	page.Url = "/page/"
+
+	g := &Generator{}
+	vars := g.getPageVariables(page)
+	lookup := func(name string) interface{} {
+		v, _ := getPathValue(vars, splitVariablePath(name))
+		return v
+	}
+
+	if lookup("title") != "Title" ||
+		lookup("layout") != "default" ||
+		lookup("url") != "/page/" ||
+		lookup("hero_image") != "/img/hero.png" ||
+		lookup("featured") != true ||
+		lookup("order") != 3 ||
+		lookup("authors[1].name") != "Author2" ||
+		lookup("authors.size") != 2 ||
+		lookup("seo.keywords.last") != "b" ||
+		lookup("tags[1]") != "yaml" ||
+		lookup("description.first") != "first" {
+
+		t.Fatalf("getPageVariables returned invalid variables")
+	}
+}
+
+func TestGetSiteVariables(t *testing.T) {
+	p := filepath.Join(t.TempDir(), "_config.yml")
+	os.WriteFile(p, []byte(""+
+		"title: Title\n"+
+		"url: http://example.com\n"+
+		"custom:\n"+
+		"  analytics_id: UA-1\n"+
+		"social:\n"+
+		"  twitter: handle\n"+
+		"menu:\n"+
+		"  - title: Home\n"+
+		"    url: /\n"+
+		"  - title: About\n"+
+		"    url: /about/\n"), 0640)
+
+	c := &Config{}
+	if err := c.SetFromFile(p); err != nil {
+		t.Fatalf("SetFromFile returned error: %s", err.Error())
+	}
+	c.Url = "http://localhost:8080"
+
+	g := &Generator{}
+	g.getSiteVariables(&Website{Config: c})
+
+	n := &Node{
+		Type: "root",
+		Values: map[string]interface{}{
+			"site": g.cachedSiteVariables,
+		},
+	}
+	n.SetFromString("{{ site.title }} {{ site.url }} {{ site.custom.analytics_id }} {{ site.social.twitter }} "+
+		"{% for item in site.menu %}{{ item.title }}={{ item.url }};{% endfor %}", '{', '}', '%')
+	n.ProcessForTags("{%", "%}", &Website{}, g)
+	n.ProcessPostVars()
+
+	if s := n.GetRaw("{%", "%}"); s != "Title http://localhost:8080 UA-1 handle Home=/;About=/about/;" {
+		t.Fatalf("getSiteVariables returned invalid variables: %s", s)
+	}
+}
+
+func TestGetPageHtmlNestedLayouts(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "default.html"), []byte("<body class=\"{{ layout.class }}\">{{ content }}</body>"), 0640)
+	os.WriteFile(filepath.Join(dir, "post.html"), []byte("---\nlayout: default\nclass: post\n---\n"+
+		"<h1>{{ page.title }}</h1><i>{{ layout.name }}</i>{{ content }}"), 0640)
+
+	w := &Website{
+		Config:  &Config{},
+		Layouts: map[string]*Layout{},
+	}
+	for _, name := range []string{"default", "post"} {
+		w.Layouts[name] = &Layout{}
+		if err := w.Layouts[name].SetFromFile(filepath.Join(dir, name+".html")); err != nil {
+			t.Fatalf("SetFromFile returned error: %s", err.Error())
+		}
+	}
+	if w.Layouts["post"].Layout != "default" || w.Layouts["default"].Layout != "" {
+		t.Fatalf("SetFromFile set invalid parent layouts")
+	}
+
+	p := &Page{
+		Name:        "post",
+		Title:       "Title",
+		Layout:      "post",
+		ContentType: "html",
+		Body:        "{% raw %}{{ content }}{% endraw %} of {{ page.title }} in {{ layout.class }}",
+	}
+	g := &Generator{}
+	g.getSiteVariables(w)
+
+	h, err := g.getPageHtml(p, w)
+	if err != nil {
+		t.Fatalf("getPageHtml returned error: %s", err.Error())
+	}
+	if h != "<body class=\"\"><h1>Title</h1><i>post</i>{{ content }} of Title in post</body>" {
+		t.Fatalf("getPageHtml returned invalid html: %s", h)
+	}
+
+	w.Layouts["default"].Layout = "post"
+	if _, err := g.getPageHtml(p, w); err == nil || !strings.Contains(err.Error(), "post -> default -> post") {
+		t.Fatalf("getPageHtml did not return layout cycle error: %v", err)
+	}
+}
+
+func TestMdToHtmlMultilineTags(t *testing.T) {
+	g := &Generator{}
+	md := "Some *text*\n\n{% include card.html\n  title=\"A_b_c\" %}\n\n{{ page.title\n  | truncate: 40, \"...\" }}\n"
+	h := g.mdToHtml(md)
+	for _, tag := range []string{"{% include card.html\n  title=\"A_b_c\" %}", "{{ page.title\n  | truncate: 40, \"...\" }}"} {
+		if !strings.Contains(h, tag) {
+			t.Fatalf("mdToHtml changed multi-line tag %s: %s", tag, h)
+		}
+	}
+	if !strings.Contains(h, "<em>text</em>") {
+		t.Fatalf("mdToHtml did not convert markdown: %s", h)
+	}
+
+	w := &Website{
+		Config: &Config{},
+	}
+	p := &Page{Name: "post", Title: "Post title", ContentType: "markdown", Body: "{{ page.title\n  | upcase }}"}
+	g.getSiteVariables(w)
+	g.cachedFilters = g.getFilters(w)
+	h, err := g.getContentHtml(p, w, nil)
+	if err != nil {
+		t.Fatalf("getContentHtml returned error: %s", err.Error())
+	}
+	if !strings.Contains(h, "POST TITLE") {
+		t.Fatalf("getContentHtml did not process multi-line output tag: %s", h)
+	}
+}
+
+func TestGetContentHtmlMultilineInclude(t *testing.T) {
+	g := &Generator{}
+	w := &Website{
+		Config: &Config{},
+		Includes: map[string]*Include{
+			"card": &Include{Name: "card", ContentType: "html", Body: "<b>{{ include.title }}</b>"},
+		},
+	}
+	p := &Page{Name: "post", Title: "Post title", ContentType: "markdown",
+		Body: "Some *text*\n\n{% include card.html\n  title=\"A_b_c\" %}\n\n{{ page.title\n  | truncate: 40, \"...\" }}\n"}
+	g.getSiteVariables(w)
+	g.cachedFilters = g.getFilters(w)
+	h, err := g.getContentHtml(p, w, nil)
+	if err != nil {
+		t.Fatalf("getContentHtml returned error: %s", err.Error())
+	}
+	if !strings.Contains(h, "<b>A_b_c</b>") || !strings.Contains(h, "Post title") || strings.Contains(h, "{{") {
+		t.Fatalf("getContentHtml did not process multi-line include tag: %s", h)
+	}
+}
+
+func TestGetContentHtmlRaw(t *testing.T) {
+	g := &Generator{}
+	w := &Website{
+		Config: &Config{Title: "Site"},
+	}
+	g.getSiteVariables(w)
+	g.cachedFilters = g.getFilters(w)
+
+	for body, expected := range map[string]string{
+		"{% raw %}{{ page.title }}{% endraw %}":                           "{{ page.title }}",
+		"{% for i in (1..2) %}{% raw %}[{{ i }}]{% endraw %}{% endfor %}": "[{{ i }}][{{ i }}]",
+		"{{ page.description }}":                                          "{{ site.title }}",
+	} {
+		p := &Page{Name: "index", Title: "Home", ContentType: "html", Body: body,
+			FrontMatter: map[string]interface{}{"description": "{{ site.title }}"}}
+		h, err := g.getContentHtml(p, w, nil)
+		if err != nil {
+			t.Fatalf("getContentHtml returned error: %s", err.Error())
+		}
+		if h != expected {
+			t.Fatalf("getContentHtml returned %s instead of %s for %s", h, expected, body)
+		}
+	}
+}
@@ -11,6 +11,7 @@ type Include struct {
 	Name        string
 	Body        string
 	ContentType string
+	Path        string
 }
 
 func (i *Include) SetFromFile(fpath string) error {
@@ -29,6 +30,7 @@ func (i *Include) SetFromFile(fpath string) error {
 	}
 
 	i.Body = string(body)
+	i.Path = fpath
 
 	return nil
 }
@@ -2,6 +2,7 @@ package main
 
 import (
 	"fmt"
+	"gopkg.in/yaml.v2"
 	"os"
 	"path/filepath"
 	"strings"
@@ -11,6 +12,11 @@ type Layout struct {
 	Name        string
 	Body        string
 	ContentType string
+	Layout      string
+	Path        string
+	BodyLine    int
+
+	FrontMatter map[string]interface{}
 }
 
 func (l *Layout) SetFromFile(fpath string) error {
@@ -29,6 +35,39 @@ func (l *Layout) SetFromFile(fpath string) error {
 	}
 
 	l.Body = string(body)
+	l.Path = fpath
+	l.BodyLine = 1
+	l.FrontMatter = map[string]interface{}{}
+
+	// front matter is optional in layouts
+	lines := strings.SplitAfter(strings.ReplaceAll(l.Body, "\r\n", "\n"), "\n")
+	if lines[0] != "---\n" {
+		return nil
+	}
+	headerEnd := -1
+	for i := 1; i < len(lines); i++ {
+		if strings.TrimSuffix(lines[i], "\n") == "---" {
+			headerEnd = i
+			break
+		}
+	}
+	if headerEnd == -1 {
+		return nil
+	}
+	header := strings.Join(lines[1:headerEnd], "")
+	l.Body = strings.Join(lines[headerEnd+1:], "")
+	l.BodyLine = headerEnd + 2
+
+	frontMatter := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(header), &frontMatter); err != nil {
+		return &SourceError{
+			Path: fpath,
+			Line: getYamlErrorLine(err, 2),
+			Err:  fmt.Errorf("Error getting front matter from YAML: %w", err),
+		}
+	}
+	l.FrontMatter = normalizeYamlValue(frontMatter).(map[string]interface{})
+	l.Layout, _ = l.FrontMatter["layout"].(string)
 
 	return nil
 }
@@ -3,6 +3,8 @@ package main
 import (
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/mikolajgs/broccli"
 )
@@ -12,6 +14,17 @@ func main() {
 	cmdGen := cli.AddCmd("generate", "Generates HTML from a specified directory", generateHandler)
 	cmdGen.AddFlag("source", "s", "", "Path to source directory", broccli.TypePathFile, broccli.IsExistent|broccli.IsDirectory|broccli.IsRequired)
 	cmdGen.AddFlag("destination", "d", "", "Path to target directory", broccli.TypePathFile, broccli.IsExistent|broccli.IsRequired)
+	cmdGen.AddFlag("watch", "w", "", "Watch source directory and regenerate on change", broccli.TypeBool, 0, broccli.OnTrue(onTrueNoop))
+	cmdGen.AddFlag("drafts", "D", "", "Include drafts and unpublished posts", broccli.TypeBool, 0, broccli.OnTrue(onTrueNoop))
+	cmdGen.AddFlag("future", "f", "", "Include posts dated in the future", broccli.TypeBool, 0, broccli.OnTrue(onTrueNoop))
+	cmdGen.AddFlag("strict", "S", "", "Fail on undefined variables, unknown filters and unclosed tags", broccli.TypeBool, 0, broccli.OnTrue(onTrueNoop))
+	cmdServe := cli.AddCmd("serve", "Generates HTML from a specified directory and serves it over HTTP", serveHandler)
+	cmdServe.AddFlag("source", "s", "", "Path to source directory", broccli.TypePathFile, broccli.IsExistent|broccli.IsDirectory|broccli.IsRequired)
+	cmdServe.AddFlag("address", "a", "", "Address to listen on, default localhost:8080", broccli.TypeString, 0)
+	cmdServe.AddFlag("watch", "w", "", "Watch source directory, rebuild on change and reload browser", broccli.TypeBool, 0, broccli.OnTrue(onTrueNoop))
+	cmdServe.AddFlag("drafts", "D", "", "Include drafts and unpublished posts", broccli.TypeBool, 0, broccli.OnTrue(onTrueNoop))
+	cmdServe.AddFlag("future", "f", "", "Include posts dated in the future", broccli.TypeBool, 0, broccli.OnTrue(onTrueNoop))
+	cmdServe.AddFlag("strict", "S", "", "Fail on undefined variables, unknown filters and unclosed tags", broccli.TypeBool, 0, broccli.OnTrue(onTrueNoop))
 	_ = cli.AddCmd("version", "Prints version", versionHandler)
 	if len(os.Args) == 2 && (os.Args[1] == "-v" || os.Args[1] == "--version") {
 		os.Args = []string{"App", "version"}
@@ -19,27 +32,111 @@ func main() {
 	os.Exit(cli.Run())
 }
 
+// broccli stores value of a bool flag only when it has an OnTrue hook attached
+func onTrueNoop(c *broccli.Cmd) {}
+
 func versionHandler(c *broccli.CLI) int {
 	fmt.Fprintf(os.Stdout, VERSION+"\n")
 	return 0
 }
 
 func generateHandler(c *broccli.CLI) int {
+	watch := c.Flag("watch") == "true"
+
 	website := Website{
 		SourcePath: c.Flag("source"),
+		Drafts:     c.Flag("drafts") == "true",
+		Future:     c.Flag("future") == "true",
 	}
 
-	if err := website.Init(); err != nil {
-		fmt.Fprintf(os.Stderr, "!!!! Error with website initialization: %s\n", err.Error())
+	gen := Generator{
+		DestinationPath: c.Flag("destination"),
+		Strict:          c.Flag("strict") == "true",
+	}
+
+	// destination is cleaned before every regeneration in watch mode so it has to be empty even when the
+	// first build fails
+	if err := gen.checkIfDestinationPathEmpty(); err != nil {
+		fmt.Fprintf(os.Stderr, "!!!! Error with generation: %s\n", err.Error())
 		return 1
 	}
 
-	gen := Generator{
-		DestinationPath: c.Flag("destination"),
+	if err := generate(website, &gen); err != nil {
+		fmt.Fprintf(os.Stderr, "!!!! %s\n", err.Error())
+		if !watch {
+			return 1
+		}
+	}
+
+	if !watch {
+		return 0
+	}
+
+	stop := make(chan struct{})
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		close(stop)
+	}()
+
+	watcher := Watcher{
+		Path:        c.Flag("source"),
+		IgnorePaths: []string{c.Flag("destination")},
+	}
+
+	fmt.Fprintf(os.Stdout, "Watching %s for changes...\n", c.Flag("source"))
+	err := watcher.Watch(stop, func() {
+		fmt.Fprintf(os.Stdout, "Change detected, regenerating...\n")
+		if err := regenerate(website, &gen); err != nil {
+			fmt.Fprintf(os.Stderr, "!!!! %s\n", err.Error())
+		}
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "!!!! Error with watching: %s\n", err.Error())
+		return 1
+	}
+
+	return 0
+}
+
+// generate initialises a copy of the website and generates it
+func generate(website Website, gen *Generator) error {
+	if err := website.Init(); err != nil {
+		return fmt.Errorf("Error with website initialization: %w", err)
 	}
 
 	if err := gen.Generate(&website); err != nil {
-		fmt.Fprintf(os.Stderr, "!!!! Error with generation: %s\n", err.Error())
+		return fmt.Errorf("Error with generation: %w", err)
+	}
+
+	return nil
+}
+
+// regenerate cleans the destination and generates the website again from scratch
+func regenerate(website Website, gen *Generator) error {
+	if err := gen.CleanDestinationPath(); err != nil {
+		return fmt.Errorf("Error cleaning destination: %w", err)
+	}
+
+	return generate(website, gen)
+}
+
+func serveHandler(c *broccli.CLI) int {
+	server := Server{
+		SourcePath: c.Flag("source"),
+		Address:    c.Flag("address"),
+		Watch:      c.Flag("watch") == "true",
+		Drafts:     c.Flag("drafts") == "true",
+		Future:     c.Flag("future") == "true",
+		Strict:     c.Flag("strict") == "true",
+	}
+	if server.Address == "" {
+		server.Address = "localhost:8080"
+	}
+
+	if err := server.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "!!!! Error with server: %s\n", err.Error())
 		return 1
 	}
 
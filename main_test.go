@@ -125,6 +125,7 @@ var testNode2b = &Node{
 }
 
 var testWebsite2 = &Website{
+	PostsNames: []string{"one", "two"},
 	Posts: map[string]*Page{
 		"one": &Page{
 			Title:       "Title1",
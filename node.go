@@ -1,10 +1,16 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"os"
+	"reflect"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"unicode"
 )
 
 type Node struct {
@@ -12,12 +18,28 @@ type Node struct {
 	Content  string
 	Children []*Node
 	Parent   *Node
-	Values   map[string]map[string]string
+	Values   map[string]interface{}
+	// Include is file name of the include that node contents come from, eg. nav/menu.html
+	Include string
+	// Source is path of the file that node contents come from, set on root and include nodes
+	Source string
+	// Line and Col are position of the tag in its source file
+	Line int
+	Col  int
+	// Raw is set on text nodes with contents of raw tag
+	Raw bool
 }
 
+// maxForRangeSize is the maximum number of items in a range in for loop, eg. (1..100)
+const maxForRangeSize = 100000
+
+// defaultMaxIncludeDepth is the maximum number of nested includes when it is not set in config
+const defaultMaxIncludeDepth = 20
+
 func (n *Node) ProcessRawTags(tagPrefix string, tagSuffix string) {
 	if n.Type == "raw" {
 		n.Type = "text"
+		n.Raw = true
 		c := ""
 		if len(n.Children) > 0 {
 			for _, child := range n.Children {
@@ -37,39 +59,151 @@ func (n *Node) ProcessRawTags(tagPrefix string, tagSuffix string) {
 }
 
 func (n *Node) GetRaw(tagPrefix string, tagSuffix string) string {
+	return n.getRaw(tagPrefix, tagSuffix, false)
+}
+
+// getRaw returns node written back as a string.  When keepRaw is true, contents of processed raw tags are
+// wrapped in raw tags again so that they stay unprocessed when the string is parsed again, eg. in a loop.
+func (n *Node) getRaw(tagPrefix string, tagSuffix string, keepRaw bool) string {
 	s := ""
-	if n.Type == "text" {
+	if n.Type == "text" && n.Raw && keepRaw {
+		s += fmt.Sprintf("%sraw%s%s%sendraw%s", tagPrefix, tagSuffix, n.Content, tagPrefix, tagSuffix)
+	} else if n.Type == "text" {
 		s += n.Content
-	} else if n.Type == "if" || n.Type == "for" || n.Type == "raw" {
-		s += fmt.Sprintf("%s%s%s", tagPrefix, n.Content, tagSuffix)
+	} else if n.isBlock() || n.Type == "elsif" || n.Type == "else" || n.Type == "include" {
+		s += fmt.Sprintf("%s%s%s%s", tagPrefix, n.getPositionAnnotation(tagPrefix), n.Content, tagSuffix)
 	}
 	if len(n.Children) > 0 {
 		for _, child := range n.Children {
-			s += child.GetRaw(tagPrefix, tagSuffix)
+			s += child.getRaw(tagPrefix, tagSuffix, keepRaw)
 		}
 	}
-	if n.Type == "if" || n.Type == "for" || n.Type == "raw" {
+	if n.isBlock() {
 		s += fmt.Sprintf("%send%s%s", tagPrefix, n.Type, tagSuffix)
 	}
 	return s
 }
 
-func (n *Node) ProcessForTags(tagPrefix string, tagSuffix string, w *Website, g *Generator) {
-	// TODO: Hardcoded!
-	if n.Type == "for" && strings.Trim(n.Content, " ") == "for post in site.posts" {
+// getPositionAnnotation returns position of the tag to be put in it when the tag is written back, so that it
+// is not lost when the tag is parsed again, eg. in a loop
+func (n *Node) getPositionAnnotation(tagPrefix string) string {
+	if n.Line == 0 || tagPrefix == "" {
+		return ""
+	}
+	return fmt.Sprintf("@%d:%d", n.Line, n.Col)
+}
+
+// newError returns error with position of the tag and the files it was included through
+func (n *Node) newError(err error) error {
+	return n.newErrorAt(err, n.Line, n.Col, n.getTagSnippet())
+}
+
+func (n *Node) newErrorAt(err error, line int, col int, snippet string) error {
+	chain := []string{}
+	for p := n; p != nil; p = p.Parent {
+		if p.Source != "" {
+			chain = append([]string{p.Source}, chain...)
+		}
+	}
+	if len(chain) == 0 {
+		return fmt.Errorf("%w in '%s'", err, snippet)
+	}
+	return &SourceError{
+		Path:    chain[len(chain)-1],
+		Line:    line,
+		Col:     col,
+		Snippet: snippet,
+		Chain:   chain,
+		Err:     err,
+	}
+}
+
+func (n *Node) getTagSnippet() string {
+	return fmt.Sprintf("{%%%s%%}", n.Content)
+}
+
+func (n *Node) isBlock() bool {
+	return n.Type == "if" || n.Type == "unless" || n.Type == "for" || n.Type == "raw"
+}
+
+// getBlockNode returns if, unless or for node that else or elsif tag at this node belongs to
+func (n *Node) getBlockNode() *Node {
+	if n.Type == "elsif" || n.Type == "else" {
+		return n.Parent
+	}
+	if n.Type == "if" || n.Type == "unless" || n.Type == "for" {
+		return n
+	}
+	return nil
+}
+
+// getBranches splits children of if, unless or for node into the first branch and elsif and else nodes
+func (n *Node) getBranches() ([]*Node, []*Node) {
+	for i, child := range n.Children {
+		if child.Type == "elsif" || child.Type == "else" {
+			return n.Children[:i], n.Children[i:]
+		}
+	}
+	return n.Children, []*Node{}
+}
+
+func (n *Node) ProcessForTags(tagPrefix string, tagSuffix string, w *Website, g *Generator) error {
+	if n.Type == "for" {
+		re := regexp.MustCompile(`(?s)^for\s+([a-zA-Z_][a-zA-Z0-9_]*)\s+in\s+(\S+)(.*)$`)
+		found := re.FindStringSubmatch(strings.TrimSpace(n.Content))
+		if len(found) != 4 {
+			return n.newError(errors.New("Invalid for tag"))
+		}
+
+		children, branches := n.getBranches()
 		forContent := ""
-		for _, ch := range n.Children {
-			forContent += ch.GetRaw(tagPrefix, tagSuffix)
+		for _, ch := range children {
+			forContent += ch.getRaw(tagPrefix, tagSuffix, true)
+		}
+
+		items, err := n.getForItems(found[2], w, g)
+		if err != nil {
+			return n.newError(err)
+		}
+		items = n.applyForModifiers(items, strings.Fields(found[3]))
+
+		if len(items) == 0 {
+			n.Children = []*Node{}
+			for _, branch := range branches {
+				if branch.Type == "else" {
+					n.Children = branch.Children
+					for _, child := range n.Children {
+						child.Parent = n
+					}
+				}
+			}
 		}
 
-		newChildren := []*Node{}
-		for _, post := range w.Posts {
+		parentLoop, _ := n.getAttachedVariable("forloop")
+
+		newChildren := n.Children
+		if len(items) > 0 {
+			newChildren = []*Node{}
+		}
+		for i, item := range items {
 			childNode := &Node{
 				Type:   "group",
-				Values: map[string]map[string]string{},
+				Values: map[string]interface{}{},
+				Parent: n,
+			}
+			childNode.Values[found[1]] = item
+			childNode.Values["forloop"] = map[string]interface{}{
+				"index":      i + 1,
+				"index0":     i,
+				"rindex":     len(items) - i,
+				"rindex0":    len(items) - i - 1,
+				"first":      i == 0,
+				"last":       i == len(items)-1,
+				"length":     len(items),
+				"parentloop": parentLoop,
 			}
-			childNode.Values["post"] = g.getObjVariablesFromYamlTag(post)
 			childNode.SetFromString(forContent, []rune(tagPrefix)[0], []rune(tagSuffix)[1], []rune(tagPrefix)[1])
+			childNode.ProcessRawTags(tagPrefix, tagSuffix)
 			newChildren = append(newChildren, childNode)
 		}
 		n.Children = newChildren
@@ -77,42 +211,256 @@ func (n *Node) ProcessForTags(tagPrefix string, tagSuffix string, w *Website, g
 	}
 	if len(n.Children) > 0 {
 		for _, child := range n.Children {
-			child.ProcessForTags(tagPrefix, tagSuffix, w, g)
+			if err := child.ProcessForTags(tagPrefix, tagSuffix, w, g); err != nil {
+				return err
+			}
 		}
 	}
+	return nil
 }
 
-func (n *Node) ProcessIfTags(siteVars map[string]string, pageVars map[string]string) {
-	if n.Type == "if" {
-		s := strings.Trim(n.Content, " ")
-		sArr := strings.Split(s, " ")
-		result := false
-		if len(sArr) > 2 || sArr[0] != "if" {
-			n.Type = "text"
-			n.Content = "INVALID IF"
-			n.Children = []*Node{}
+// ProcessIncludeTags expands includes.  It runs after for and if tags are processed so that include parameters
+// can use loop variables, eg. {% for post in site.posts %}{% include card.html title=post.title %}{% endfor %},
+// and includes in branches that are not rendered are not loaded.  Tags in included contents are processed the
+// same way.
+func (n *Node) ProcessIncludeTags(tagPrefix string, tagSuffix string, w *Website, g *Generator, siteVars map[string]interface{}, pageVars map[string]interface{}) error {
+	if n.Type == "include" {
+		if err := n.processIncludeTag(tagPrefix, tagSuffix, w, g); err != nil {
+			return err
 		}
+		n.ProcessRawTags(tagPrefix, tagSuffix)
+		if err := n.ProcessForTags(tagPrefix, tagSuffix, w, g); err != nil {
+			return err
+		}
+		if err := n.ProcessIfTags(siteVars, pageVars); err != nil {
+			return err
+		}
+	}
+	for _, child := range n.Children {
+		if err := child.ProcessIncludeTags(tagPrefix, tagSuffix, w, g, siteVars, pageVars); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-		re := regexp.MustCompile(`(site|page|post)\.([a-zA-Z0-9\-\_]+)`)
-		found := re.FindStringSubmatch(sArr[1])
-		if len(found) != 3 {
-			n.Type = "Text"
-			n.Content = "INVALID IF"
-			n.Children = []*Node{}
+// processIncludeTag replaces include tag with contents of the include, with its parameters attached as
+// include variable, eg. {% include nav/menu.html title=page.title active="home" %}
+func (n *Node) processIncludeTag(tagPrefix string, tagSuffix string, w *Website, g *Generator) error {
+	tag := strings.TrimSpace(n.Content)
+	re := regexp.MustCompile(`(?s)^include\s+([a-zA-Z0-9\-\_/]+)\.(html|markdown)(.*)$`)
+	found := re.FindStringSubmatch(tag)
+	if len(found) != 4 {
+		return n.newError(errors.New("Invalid include tag"))
+	}
+
+	name := found[1]
+	if w.Includes[name] == nil {
+		return n.newError(fmt.Errorf("Include %s does not exist", name))
+	}
+
+	params, err := n.getIncludeParams(found[3], w, g)
+	if err != nil {
+		return n.newError(fmt.Errorf("Invalid include tag: %w", err))
+	}
+
+	// an include can include itself, eg. to render a tree, as long as parameters change on the way; when they
+	// do not, it would be rendered the same way forever
+	include := name + "." + found[2]
+	chain := append(n.getIncludeChain(), include)
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Include == include && reflect.DeepEqual(p.Values["include"], params) {
+			return n.newError(fmt.Errorf("Include cycle detected: %s", strings.Join(chain, " -> ")))
 		}
+	}
+	maxDepth := defaultMaxIncludeDepth
+	if w.Config != nil && w.Config.MaxIncludeDepth > 0 {
+		maxDepth = w.Config.MaxIncludeDepth
+	}
+	if len(chain) > maxDepth {
+		return n.newError(fmt.Errorf("Includes are nested deeper than %d: %s", maxDepth, strings.Join(chain, " -> ")))
+	}
 
-		if found[1] == "site" {
-			if siteVars[found[2]] != "" {
-				result = true
+	body := w.Includes[name].Body
+	if w.Includes[name].Path != "" {
+		body = annotateTagPositions(body, 1)
+	}
+	if w.Includes[name].ContentType == "markdown" {
+		body = g.mdToHtml(body)
+	}
+
+	n.Type = "group"
+	n.Content = ""
+	n.Include = include
+	n.Source = w.Includes[name].Path
+	n.Values = map[string]interface{}{
+		"include": params,
+	}
+	n.Children = []*Node{}
+	n.SetFromString(body, []rune(tagPrefix)[0], []rune(tagSuffix)[1], []rune(tagPrefix)[1])
+
+	return nil
+}
+
+// getIncludeChain returns names of includes that the node is in, starting from the outermost one
+func (n *Node) getIncludeChain() []string {
+	chain := []string{}
+	for p := n; p != nil; p = p.Parent {
+		if p.Include != "" {
+			chain = append([]string{p.Include}, chain...)
+		}
+	}
+	return chain
+}
+
+// getIncludeParams returns values of include parameters, eg. title=page.title url="/about/"
+func (n *Node) getIncludeParams(s string, w *Website, g *Generator) (map[string]interface{}, error) {
+	params := map[string]interface{}{}
+
+	re := regexp.MustCompile(`^\s*([a-zA-Z0-9\-\_]+)\s*=\s*("[^"]*"|'[^']*'|[^\s"']+)`)
+	s = strings.TrimRightFunc(s, unicode.IsSpace)
+	for s != "" {
+		found := re.FindStringSubmatch(s)
+		if found == nil {
+			return nil, fmt.Errorf("Invalid parameters '%s'", strings.TrimSpace(s))
+		}
+
+		expr := &Expression{
+			Source: found[2],
+		}
+		if err := expr.Parse(); err != nil {
+			return nil, fmt.Errorf("Invalid value of parameter %s: %w", found[1], err)
+		}
+		params[found[1]] = expr.Evaluate(func(name string) (interface{}, bool) {
+			return n.lookupVariable(name, g.getSiteVariablesFor(w), nil)
+		})
+
+		s = s[len(found[0]):]
+	}
+
+	return params, nil
+}
+
+// getForItems returns list of items for loop expression which can be either a variable, eg. site.posts or
+// page.tags, or a range, eg. (1..5) or (1..site.count)
+func (n *Node) getForItems(expr string, w *Website, g *Generator) ([]interface{}, error) {
+	re := regexp.MustCompile(`^\(([^\.]+)\.\.([^\)]+)\)$`)
+	found := re.FindStringSubmatch(expr)
+	if len(found) == 3 {
+		from, fromOk := valueToFloat(n.getRangeLimit(found[1], w, g))
+		to, toOk := valueToFloat(n.getRangeLimit(found[2], w, g))
+		items := []interface{}{}
+		if !fromOk || !toOk {
+			return items, nil
+		}
+		if from != math.Trunc(from) || to != math.Trunc(to) {
+			return nil, fmt.Errorf("Range %s must have whole numbers as limits", expr)
+		}
+		if math.Abs(from) > math.MaxInt32 || math.Abs(to) > math.MaxInt32 {
+			return nil, fmt.Errorf("Range %s has limits that are too large", expr)
+		}
+		if to-from+1 > maxForRangeSize {
+			return nil, fmt.Errorf("Range %s has more than %d items", expr, maxForRangeSize)
+		}
+		for i := int(from); i <= int(to); i++ {
+			items = append(items, i)
+		}
+		return items, nil
+	}
+
+	v, ok := n.lookupVariable(expr, g.getSiteVariablesFor(w), nil)
+	if !ok {
+		return []interface{}{}, nil
+	}
+	return valueToList(v), nil
+}
+
+func (n *Node) getRangeLimit(s string, w *Website, g *Generator) interface{} {
+	s = strings.Trim(s, " ")
+	if _, err := strconv.ParseFloat(s, 64); err == nil {
+		return s
+	}
+	v, _ := n.lookupVariable(s, g.getSiteVariablesFor(w), nil)
+	return v
+}
+
+// applyForModifiers applies sort_by, offset, limit and reversed (in that order) to the list of items
+func (n *Node) applyForModifiers(items []interface{}, modifiers []string) []interface{} {
+	mods := map[string]string{}
+	for i := 0; i < len(modifiers); i++ {
+		m := modifiers[i]
+		if strings.HasSuffix(m, ":") && i+1 < len(modifiers) {
+			m += modifiers[i+1]
+			i++
+		}
+		mArr := strings.SplitN(m, ":", 2)
+		if len(mArr) == 2 {
+			mods[mArr[0]] = strings.Trim(mArr[1], "\"'")
+		} else {
+			mods[mArr[0]] = ""
+		}
+	}
+
+	if key, ok := mods["sort_by"]; ok && key != "" {
+		path := splitVariablePath(key)
+		sorted := make([]interface{}, len(items))
+		copy(sorted, items)
+		sort.SliceStable(sorted, func(i, j int) bool {
+			vi, _ := getPathValue(sorted[i], path)
+			vj, _ := getPathValue(sorted[j], path)
+			return compareValues(vi, vj) < 0
+		})
+		items = sorted
+	}
+
+	if offset, err := strconv.Atoi(mods["offset"]); err == nil && offset > 0 {
+		if offset > len(items) {
+			offset = len(items)
+		}
+		items = items[offset:]
+	}
+
+	if limit, err := strconv.Atoi(mods["limit"]); err == nil && limit >= 0 && limit < len(items) {
+		items = items[:limit]
+	}
+
+	if _, ok := mods["reversed"]; ok {
+		reversed := make([]interface{}, len(items))
+		for i, item := range items {
+			reversed[len(items)-1-i] = item
+		}
+		items = reversed
+	}
+
+	return items
+}
+
+func (n *Node) ProcessIfTags(siteVars map[string]interface{}, pageVars map[string]interface{}) error {
+	if n.Type == "if" || n.Type == "unless" {
+		children, branches := n.getBranches()
+
+		result, err := n.evaluateCondition(n.Content, siteVars, pageVars)
+		if err != nil {
+			return err
+		}
+		if n.Type == "unless" {
+			result = !result
+		}
+
+		for _, branch := range branches {
+			if result {
+				break
 			}
-		} else if found[1] == "page" {
-			if pageVars[found[2]] != "" {
+			if branch.Type == "else" {
+				children = branch.Children
 				result = true
+				break
 			}
-		} else if found[1] == "post" {
-			if n.GetNodeAttachedValue("post", found[2]) != "" {
-				result = true
+			result, err = branch.evaluateCondition(branch.Content, siteVars, pageVars)
+			if err != nil {
+				return err
 			}
+			children = branch.Children
 		}
 
 		if !result {
@@ -122,36 +470,157 @@ func (n *Node) ProcessIfTags(siteVars map[string]string, pageVars map[string]str
 		} else {
 			n.Type = "group"
 			n.Content = ""
+			n.Children = children
+			for _, child := range n.Children {
+				child.Parent = n
+			}
 		}
 	}
 	if len(n.Children) > 0 {
 		for _, child := range n.Children {
-			child.ProcessIfTags(siteVars, pageVars)
+			if err := child.ProcessIfTags(siteVars, pageVars); err != nil {
+				return err
+			}
 		}
 	}
+	return nil
+}
+
+// evaluateCondition evaluates condition of if, unless and elsif tag, eg. 'if page.title == "Home"'
+func (n *Node) evaluateCondition(s string, siteVars map[string]interface{}, pageVars map[string]interface{}) (bool, error) {
+	sArr := regexp.MustCompile(`\s+`).Split(strings.TrimSpace(s), 2)
+	if len(sArr) != 2 || (sArr[0] != "if" && sArr[0] != "unless" && sArr[0] != "elsif") {
+		return false, n.newError(errors.New("Invalid condition"))
+	}
+
+	expr := &Expression{
+		Source: strings.TrimSpace(sArr[1]),
+	}
+	if err := expr.Parse(); err != nil {
+		return false, n.newError(fmt.Errorf("Invalid condition: %w", err))
+	}
+
+	v := expr.Evaluate(func(name string) (interface{}, bool) {
+		return n.lookupVariable(name, siteVars, pageVars)
+	})
+	return isTruthy(v), nil
 }
 
+// ProcessPostVars replaces output tags with values of variables attached to nodes, eg. loop variable such as post
 func (n *Node) ProcessPostVars() {
-	re := regexp.MustCompile(`\{\{[ ]*post\.([a-zA-Z0-9\-\_]+)[ ]*\}\}`)
-	if n.Type == "text" {
-		for _, found := range re.FindAllStringSubmatch(n.Content, -1) {
-			varName := strings.Trim(string(found[1]), " ")
-			replaceWith := n.GetNodeAttachedValue("post", varName)
-			n.Content = strings.ReplaceAll(n.Content, found[0], replaceWith)
+	n.ProcessOutputTags(map[string]Filter{})
+}
+
+// ProcessOutputTags replaces output tags, eg. {{ post.title | upcase }}, in text nodes.  Tags with variables
+// that cannot be found are left as they are.
+func (n *Node) ProcessOutputTags(filters map[string]Filter) error {
+	re := regexp.MustCompile(`(?s)\{\{(.*?)\}\}`)
+	if n.Type == "text" && !n.Raw {
+		var err error
+		n.Content = re.ReplaceAllStringFunc(n.Content, func(tag string) string {
+			if err != nil {
+				return tag
+			}
+			line, col, source := parseTagPosition(tag[2 : len(tag)-2])
+			out := &Output{
+				Source: strings.TrimSpace(source),
+			}
+			if out.Parse() != nil {
+				return tag
+			}
+			if root := out.GetVariableRoot(); root != "" {
+				if _, ok := n.getAttachedVariable(root); !ok {
+					return tag
+				}
+			}
+			s, renderErr := out.Render(func(name string) (interface{}, bool) {
+				return n.lookupVariable(name, nil, nil)
+			}, filters)
+			if renderErr != nil {
+				err = n.newErrorAt(renderErr, line, col, "{{"+source+"}}")
+				return tag
+			}
+			return s
+		})
+		if err != nil {
+			return err
 		}
 	}
 	for _, ch := range n.Children {
-		ch.ProcessPostVars()
+		if err := ch.ProcessOutputTags(filters); err != nil {
+			return err
+		}
 	}
+	return nil
+}
+
+// CheckOutputTags returns undefined variables and unknown filters in output tags of text nodes.  It should be
+// called when for and if tags are processed so that only outputs that are rendered are checked.
+func (n *Node) CheckOutputTags(filters map[string]Filter) []error {
+	problems := []error{}
+	if n.Type == "text" && !n.Raw {
+		re := regexp.MustCompile(`(?s)\{\{(.*?)\}\}`)
+		for _, tag := range re.FindAllString(n.Content, -1) {
+			line, col, source := parseTagPosition(tag[2 : len(tag)-2])
+			out := &Output{
+				Source: strings.TrimSpace(source),
+			}
+			if out.Parse() != nil {
+				continue
+			}
+			snippet := "{{" + source + "}}"
+			undefined := out.GetUndefinedVariables(func(name string) (interface{}, bool) {
+				return n.lookupVariable(name, nil, nil)
+			})
+			for _, name := range undefined {
+				problems = append(problems, n.newErrorAt(fmt.Errorf("Undefined variable %s", name), line, col, snippet))
+			}
+			for _, name := range out.GetUnknownFilters(filters) {
+				problems = append(problems, n.newErrorAt(fmt.Errorf("Unknown filter %s", name), line, col, snippet))
+			}
+		}
+	}
+	for _, ch := range n.Children {
+		problems = append(problems, ch.CheckOutputTags(filters)...)
+	}
+	return problems
 }
 
 func (n *Node) GetNodeAttachedValue(objName string, varName string) string {
-	if n.Values[objName] != nil {
-		return n.Values[objName][varName]
-	} else if n.Type != "root" {
-		return n.Parent.GetNodeAttachedValue(objName, varName)
+	obj, ok := n.getAttachedVariable(objName)
+	if !ok {
+		return ""
+	}
+	v, _ := getPathValue(obj, splitVariablePath(varName))
+	return valueToString(v)
+}
+
+func (n *Node) getAttachedVariable(name string) (interface{}, bool) {
+	if v, ok := n.Values[name]; ok {
+		return v, true
+	}
+	if n.Parent != nil {
+		return n.Parent.getAttachedVariable(name)
 	}
-	return ""
+	return nil, false
+}
+
+// lookupVariable returns value of a variable, eg. post.title, checking values attached to nodes first and then
+// site and page variables
+func (n *Node) lookupVariable(name string, siteVars map[string]interface{}, pageVars map[string]interface{}) (interface{}, bool) {
+	path := splitVariablePath(name)
+	obj, ok := n.getAttachedVariable(path[0])
+	if !ok {
+		if path[0] == "site" && siteVars != nil {
+			obj, ok = siteVars, true
+		} else if path[0] == "page" && pageVars != nil {
+			obj, ok = pageVars, true
+		}
+	}
+	if !ok {
+		return nil, false
+	}
+	return getPathValue(obj, path[1:])
 }
 
 func (n *Node) SetFromString(h string, openRune rune, closeRune rune, tagRune rune) {
@@ -176,8 +645,10 @@ func (n *Node) SetFromString(h string, openRune rune, closeRune rune, tagRune ru
 		if ch == closeRune && prevCh == tagRune && tagStarted {
 			tagStarted = false
 
+			line, col, contents := parseTagPosition(tagContents)
+			tagContents = contents
 			tagName := n.getTagName(tagContents, openRune, closeRune, tagRune)
-			if tagName == "if" || tagName == "for" || tagName == "raw" {
+			if tagName == "if" || tagName == "unless" || tagName == "for" || tagName == "raw" {
 				node1 := &Node{
 					Type:    "text",
 					Content: string(text),
@@ -187,20 +658,75 @@ func (n *Node) SetFromString(h string, openRune rune, closeRune rune, tagRune ru
 				node2 := &Node{
 					Type:     tagName,
 					Content:  tagContents,
+					Line:     line,
+					Col:      col,
 					Children: []*Node{},
 					Parent:   lastNode,
 				}
 				lastNode.Children = append(lastNode.Children, node2)
 				lastNode = node2
-			}
-			if tagName == "endif" || tagName == "endfor" || tagName == "endraw" {
+			} else if tagName == "include" {
+				node1 := &Node{
+					Type:    "text",
+					Content: string(text),
+					Parent:  lastNode,
+				}
+				lastNode.Children = append(lastNode.Children, node1)
+				node2 := &Node{
+					Type:     tagName,
+					Content:  tagContents,
+					Line:     line,
+					Col:      col,
+					Children: []*Node{},
+					Parent:   lastNode,
+				}
+				lastNode.Children = append(lastNode.Children, node2)
+			} else if (tagName == "elsif" || tagName == "else") && lastNode.getBlockNode() != nil {
+				node1 := &Node{
+					Type:    "text",
+					Content: string(text),
+					Parent:  lastNode,
+				}
+				lastNode.Children = append(lastNode.Children, node1)
+				blockNode := lastNode.getBlockNode()
+				node2 := &Node{
+					Type:     tagName,
+					Content:  tagContents,
+					Line:     line,
+					Col:      col,
+					Children: []*Node{},
+					Parent:   blockNode,
+				}
+				blockNode.Children = append(blockNode.Children, node2)
+				lastNode = node2
+			} else if (tagName == "endif" || tagName == "endunless" || tagName == "endfor" || tagName == "endraw") && lastNode != n {
 				node := &Node{
 					Type:    "text",
 					Content: string(text),
 					Parent:  lastNode,
 				}
 				lastNode.Children = append(lastNode.Children, node)
+				if lastNode.Type == "elsif" || lastNode.Type == "else" {
+					lastNode = lastNode.Parent
+				}
 				lastNode = lastNode.Parent
+			} else {
+				// unknown tags are left as they are
+				text = append(text, openRune, tagRune)
+				text = append(text, []rune(tagContents)...)
+				text = append(text, tagRune, closeRune)
+				tagContents = ""
+				prevPrevCh = prevCh
+				prevCh = ch
+				if i == len(runes)-1 {
+					node := &Node{
+						Type:    "text",
+						Content: string(text),
+						Parent:  lastNode,
+					}
+					lastNode.Children = append(lastNode.Children, node)
+				}
+				continue
 			}
 
 			tagContents = ""
@@ -217,12 +743,11 @@ func (n *Node) SetFromString(h string, openRune rune, closeRune rune, tagRune ru
 			continue
 		}
 
-		if !tagStarted && !(prevCh == closeRune && prevPrevCh == tagRune) {
-			if i > 0 && i != len(runes)-1 {
+		if !tagStarted {
+			if i > 0 && !(prevCh == closeRune && prevPrevCh == tagRune) {
 				text = append(text, prevCh)
 			}
 			if i == len(runes)-1 {
-				text = append(text, prevCh)
 				text = append(text, ch)
 
 				node := &Node{
@@ -242,9 +767,11 @@ func (n *Node) SetFromString(h string, openRune rune, closeRune rune, tagRune ru
 func (n *Node) getTagName(s string, openRune rune, closeRune rune, tagRune rune) string {
 	s = strings.Replace(s, string([]rune{openRune, tagRune}), "", -1)
 	s = strings.Replace(s, string([]rune{tagRune, closeRune}), "", -1)
-	s = strings.Trim(s, " ")
-	sArr := strings.Split(s, " ")
-	return sArr[0]
+	fields := strings.Fields(s)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
 }
 
 func (n *Node) Debug(depth int) {
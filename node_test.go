@@ -1,6 +1,7 @@
 package main
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -39,10 +40,10 @@ func TestProcessForTags(t *testing.T) {
 }
 
 func TestProcessIfTags(t *testing.T) {
-	siteVars := map[string]string{
+	siteVars := map[string]interface{}{
 		"title": "SiteTitle",
 	}
-	pageVars := map[string]string{
+	pageVars := map[string]interface{}{
 		"title": "PageTitle",
 	}
 
@@ -157,3 +158,282 @@ func TestSetFromString(t *testing.T) {
 		t.Fatalf("SetFromString failed to parse text blocks")
 	}
 }
+
+func TestProcessForTagsModifiers(t *testing.T) {
+	w := &Website{
+		PostsNames: []string{"c", "a", "b", "d"},
+		Posts: map[string]*Page{
+			"a": &Page{Title: "A"},
+			"b": &Page{Title: "B"},
+			"c": &Page{Title: "C"},
+			"d": &Page{Title: "D"},
+		},
+	}
+
+	for content, expected := range map[string]string{
+		"for post in site.posts":                                "CABD",
+		"for post in site.posts limit:2":                        "CA",
+		"for post in site.posts offset:1 limit: 2":              "AB",
+		"for post in site.posts reversed":                       "DBAC",
+		"for post in site.posts sort_by:title":                  "ABCD",
+		"for post in site.posts sort_by:title reversed":         "DCBA",
+		"for post in site.posts sort_by:title offset:3":         "D",
+		"for post in site.posts limit:2 offset:10":              "",
+		"for post in site.posts sort_by:title limit:3 reversed": "CBA",
+	} {
+		n := &Node{Type: "root"}
+		n.SetFromString("{%"+content+"%}{{ post.title }}{%endfor%}", '{', '}', '%')
+		n.ProcessForTags("{%", "%}", w, &Generator{})
+		n.ProcessPostVars()
+		if s := n.GetRaw("{%", "%}"); s != expected {
+			t.Fatalf("ProcessForTags returned %s instead of %s for %s", s, expected, content)
+		}
+	}
+}
+
+func TestProcessForTagsSortByFilenameDate(t *testing.T) {
+	w := &Website{
+		PostsNames: []string{"2021-06-01-new", "2019-03-01-old", "2020-01-01-mid"},
+		Posts: map[string]*Page{
+			"2021-06-01-new": &Page{Name: "2021-06-01-new", Title: "New"},
+			"2019-03-01-old": &Page{Name: "2019-03-01-old", Title: "Old"},
+			"2020-01-01-mid": &Page{Name: "2020-01-01-mid", Title: "Mid", Date: "2020-01-01 10:00"},
+		},
+	}
+	for _, p := range w.Posts {
+		if err := p.SetTime(); err != nil {
+			t.Fatalf("SetTime returned error: %s", err.Error())
+		}
+	}
+
+	n := &Node{Type: "root"}
+	n.SetFromString("{% for post in site.posts sort_by:date %}{{ post.title }} {{ post.date }};{% endfor %}", '{', '}', '%')
+	if err := n.ProcessForTags("{%", "%}", w, &Generator{}); err != nil {
+		t.Fatalf("ProcessForTags returned error: %s", err.Error())
+	}
+	n.ProcessPostVars()
+	expected := "Old 2019-03-01T00:00:00Z;Mid 2020-01-01 10:00;New 2021-06-01T00:00:00Z;"
+	if s := n.GetRaw("{%", "%}"); s != expected {
+		t.Fatalf("ProcessForTags returned %s instead of %s", s, expected)
+	}
+}
+
+func TestProcessForTagsGeneric(t *testing.T) {
+	w := &Website{
+		PostsNames: []string{"a", "b"},
+		Posts: map[string]*Page{
+			"a": &Page{Title: "A", Categories: StringList{"cat1", "cat2"}, Tags: StringList{"go"}},
+			"b": &Page{Title: "B", Categories: StringList{"cat2"}},
+		},
+		PageNames: []string{"about", "index"},
+		Pages: map[string]*Page{
+			"about": &Page{Title: "About"},
+			"index": &Page{Title: "Home"},
+		},
+	}
+
+	for content, expected := range map[string]string{
+		"{%for i in (1..3)%}{{ i }},{%endfor%}":                                                      "1,2,3,",
+		"{%for i in (2..site.pages.size)%}{{i}}{%endfor%}":                                           "2",
+		"{%for page in site.pages%}{{ page.title }},{%endfor%}":                                      "About,Home,",
+		"{%for c in site.categories%}{{ c[0] }}:{%for p in c[1]%}{{ p.title }}{%endfor%};{%endfor%}": "cat1:A;cat2:AB;",
+		"{%for t in site.tags%}{{ t.first }}={{ t.last.size }}{%endfor%}":                            "go=1",
+		"{%for post in site.posts%}{%if post.tags%}{{ post.title }}{%endif%}{%endfor%}":              "A",
+		"{%for x in site.missing%}X{%endfor%}":                                                       "",
+		"{%for i\n  in (1..2)\n  reversed%}{{ i }}{%endfor%}":                                        "21",
+	} {
+		n := &Node{Type: "root"}
+		n.SetFromString(content, '{', '}', '%')
+		g := &Generator{}
+		n.ProcessForTags("{%", "%}", w, g)
+		n.ProcessIfTags(g.getSiteVariablesFor(w), map[string]interface{}{})
+		n.ProcessPostVars()
+		if s := n.GetRaw("{%", "%}"); s != expected {
+			t.Fatalf("ProcessForTags returned %s instead of %s for %s", s, expected, content)
+		}
+	}
+
+	n := &Node{Type: "root"}
+	n.SetFromString("{%for in site.posts%}X{%endfor%}", '{', '}', '%')
+	if err := n.ProcessForTags("{%", "%}", w, &Generator{}); err == nil {
+		t.Fatalf("ProcessForTags failed to return error on invalid for tag")
+	}
+
+	for _, content := range []string{
+		"{%for i in (1..1000000000)%}X{%endfor%}",
+		"{%for i in (-1e300..1)%}X{%endfor%}",
+		"{%for i in (1..2.5)%}X{%endfor%}",
+	} {
+		n = &Node{Type: "root"}
+		n.SetFromString(content, '{', '}', '%')
+		if err := n.ProcessForTags("{%", "%}", w, &Generator{}); err == nil {
+			t.Fatalf("ProcessForTags failed to return error on invalid range in %s", content)
+		}
+	}
+}
+
+func TestProcessForTagsForloop(t *testing.T) {
+	w := &Website{}
+	for content, expected := range map[string]string{
+		"{%for i in (1..3)%}{{ forloop.index }}/{{ forloop.length }}{%if forloop.last%}.{%endif%}{%endfor%}":             "1/32/33/3.",
+		"{%for i in (5..7)%}{{ forloop.index0 }}{{ forloop.rindex }}{{ forloop.rindex0 }}{{ forloop.first }},{%endfor%}": "032true,121false,210false,",
+		"{%for i in (1..2)%}{%for j in (1..2)%}{{ forloop.parentloop.index }}{{ forloop.index }} {%endfor%}{%endfor%}":   "11 12 21 22 ",
+	} {
+		n := &Node{Type: "root"}
+		n.SetFromString(content, '{', '}', '%')
+		n.ProcessForTags("{%", "%}", w, &Generator{})
+		n.ProcessIfTags(map[string]interface{}{}, map[string]interface{}{})
+		n.ProcessPostVars()
+		if s := n.GetRaw("{%", "%}"); s != expected {
+			t.Fatalf("ProcessForTags returned %s instead of %s for %s", s, expected, content)
+		}
+	}
+}
+
+func TestProcessIfTagsBranches(t *testing.T) {
+	siteVars := map[string]interface{}{
+		"title": "SiteTitle",
+	}
+	pageVars := map[string]interface{}{
+		"title": "PageTitle",
+	}
+
+	for content, expected := range map[string]string{
+		"{%if page.title%}A{%else%}B{%endif%}":                                      "A",
+		"{%if page.missing%}A{%else%}B{%endif%}":                                    "B",
+		"{%if page.missing%}A{%elsif site.title%}B{%else%}C{%endif%}":               "B",
+		"{%if page.missing%}A{%elsif site.missing%}B{%else%}C{%endif%}":             "C",
+		"{%if page.missing%}A{%elsif site.missing%}B{%endif%}!":                     "!",
+		"{%unless page.title%}A{%else%}B{%endunless%}":                              "B",
+		"{%unless page.missing%}A{%endunless%}":                                     "A",
+		"{%if page.title%}{%if site.missing%}A{%else%}B{%endif%}{%else%}C{%endif%}": "B",
+		"X{% comment %}Y": "X{% comment %}Y",
+		"{%if\n  page.missing%}A{%elsif\tsite.title ==\n  \"SiteTitle\"%}B{%endif%}": "B",
+		"{%raw%}{%if page.title%}A{%else%}B{%endif%}{%endraw%}":                      "{%if page.title%}A{%else%}B{%endif%}",
+	} {
+		n := &Node{Type: "root"}
+		n.SetFromString(content, '{', '}', '%')
+		n.ProcessRawTags("{%", "%}")
+		n.ProcessIfTags(siteVars, pageVars)
+		if s := n.GetRaw("{%", "%}"); s != expected {
+			t.Fatalf("ProcessIfTags returned %s instead of %s for %s", s, expected, content)
+		}
+	}
+}
+
+func TestProcessForTagsElse(t *testing.T) {
+	w := &Website{}
+	for content, expected := range map[string]string{
+		"{%for i in (1..2)%}{{ i }}{%else%}Empty{%endfor%}":                    "12",
+		"{%for i in site.posts%}{{ i }}{%else%}Empty{%endfor%}!":               "Empty!",
+		"{%for i in (1..2)%}{%if forloop.first%}F{%else%}N{%endif%}{%endfor%}": "FN",
+	} {
+		n := &Node{Type: "root"}
+		n.SetFromString(content, '{', '}', '%')
+		n.ProcessForTags("{%", "%}", w, &Generator{})
+		n.ProcessIfTags(map[string]interface{}{}, map[string]interface{}{})
+		n.ProcessPostVars()
+		if s := n.GetRaw("{%", "%}"); s != expected {
+			t.Fatalf("ProcessForTags returned %s instead of %s for %s", s, expected, content)
+		}
+	}
+}
+
+func TestProcessIncludeTags(t *testing.T) {
+	w := &Website{
+		Config:     &Config{Title: "Site"},
+		PostsNames: []string{"a", "b"},
+		Posts: map[string]*Page{
+			"a": &Page{Title: "A", Url: "/a/"},
+			"b": &Page{Title: "B", Url: "/b/"},
+		},
+		Includes: map[string]*Include{
+			"card":     &Include{Name: "card", ContentType: "html", Body: `<a href="{{ include.url }}">{{ include.title | upcase }}</a>`},
+			"nav/menu": &Include{Name: "nav/menu", ContentType: "html", Body: `{% if include.active %}[{{ include.active }}]{% endif %}{% include card.html title=site.title url="/" %}`},
+			"note":     &Include{Name: "note", ContentType: "markdown", Body: "*{{ include.text }}*"},
+		},
+	}
+
+	for content, expected := range map[string]string{
+		`{%for post in site.posts%}{% include card.html title=post.title url=post.url %};{%endfor%}`: `<a href="/a/">A</a>;<a href="/b/">B</a>;`,
+		`{% include nav/menu.html active='home' %}`:                                                  `[home]<a href="/">SITE</a>`,
+		`{% include nav/menu.html %}`:                                                                `<a href="/">SITE</a>`,
+		`{% include note.markdown text="Hi" %}`:                                                      "<p><em>Hi</em></p>\n",
+		`{% raw %}{% include card.html %}{% endraw %}`:                                               `{% include card.html %}`,
+		`{% if site.missing %}{% include missing.html %}{% endif %}`:                                 ``,
+	} {
+		n := &Node{Type: "root"}
+		n.SetFromString(content, '{', '}', '%')
+		g := &Generator{}
+		n.ProcessRawTags("{%", "%}")
+		n.ProcessForTags("{%", "%}", w, g)
+		n.ProcessIfTags(g.getSiteVariablesFor(w), map[string]interface{}{})
+		if err := n.ProcessIncludeTags("{%", "%}", w, g, g.getSiteVariablesFor(w), map[string]interface{}{}); err != nil {
+			t.Fatalf("ProcessIncludeTags returned error for %s: %s", content, err.Error())
+		}
+		n.ProcessOutputTags(g.getDefaultFilters(w))
+		if s := n.GetRaw("{%", "%}"); s != expected {
+			t.Fatalf("ProcessIncludeTags returned %s instead of %s for %s", s, expected, content)
+		}
+	}
+
+	for _, content := range []string{
+		`{% include missing.html %}`,
+		`{% include card.html title %}`,
+		`{% include card.txt %}`,
+	} {
+		n := &Node{Type: "root"}
+		n.SetFromString(content, '{', '}', '%')
+		if err := n.ProcessIncludeTags("{%", "%}", w, &Generator{}, nil, nil); err == nil {
+			t.Fatalf("ProcessIncludeTags failed to return error on invalid include tag %s", content)
+		}
+	}
+}
+
+func TestProcessIncludeTagsRecursion(t *testing.T) {
+	w := &Website{
+		Config: &Config{},
+		Includes: map[string]*Include{
+			"a":     &Include{Name: "a", ContentType: "html", Body: `a{% include b.html %}`},
+			"b":     &Include{Name: "b", ContentType: "html", Body: `b{% for i in (1..2) %}{% include a.html %}{% endfor %}`},
+			"deep1": &Include{Name: "deep1", ContentType: "html", Body: `1{% include deep2.html %}`},
+			"deep2": &Include{Name: "deep2", ContentType: "html", Body: `2{% include deep3.html %}`},
+			"deep3": &Include{Name: "deep3", ContentType: "html", Body: `3`},
+			"self":  &Include{Name: "self", ContentType: "html", Body: `A{% if include.stop %}.{% else %}{% include self.html stop=true %}{% endif %}`},
+		},
+	}
+
+	n := &Node{Type: "root"}
+	n.SetFromString(`{% include deep1.html %}`, '{', '}', '%')
+	if err := n.ProcessIncludeTags("{%", "%}", w, &Generator{}, nil, nil); err != nil {
+		t.Fatalf("ProcessIncludeTags returned error: %s", err.Error())
+	}
+	if s := n.GetRaw("{%", "%}"); s != "123" {
+		t.Fatalf("ProcessIncludeTags returned %s instead of 123", s)
+	}
+
+	// including itself is allowed when parameters change and a condition stops it
+	n = &Node{Type: "root"}
+	n.SetFromString(`{% include self.html %}`, '{', '}', '%')
+	if err := n.ProcessIncludeTags("{%", "%}", w, &Generator{}, nil, nil); err != nil {
+		t.Fatalf("ProcessIncludeTags returned error: %s", err.Error())
+	}
+	if s := n.GetRaw("{%", "%}"); s != "AA." {
+		t.Fatalf("ProcessIncludeTags returned %s instead of AA.", s)
+	}
+
+	n = &Node{Type: "root"}
+	n.SetFromString(`{% include a.html %}`, '{', '}', '%')
+	err := n.ProcessIncludeTags("{%", "%}", w, &Generator{}, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "a.html -> b.html -> a.html") {
+		t.Fatalf("ProcessIncludeTags did not return include cycle error: %v", err)
+	}
+
+	w.Config.MaxIncludeDepth = 2
+	n = &Node{Type: "root"}
+	n.SetFromString(`{% include deep1.html %}`, '{', '}', '%')
+	err = n.ProcessIncludeTags("{%", "%}", w, &Generator{}, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "deep1.html -> deep2.html -> deep3.html") {
+		t.Fatalf("ProcessIncludeTags did not return max include depth error: %v", err)
+	}
+}
@@ -2,26 +2,70 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"gopkg.in/yaml.v2"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
+var dateLayouts = []string{
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04 -0700",
+	"2006-01-02 15:04",
+	time.RFC3339,
+	"2006-01-02",
+}
+
 type Page struct {
 	Name        string
 	ContentType string
-	Layout      string `yaml:"layout"`
-	Title       string `yaml:"title"`
-	Permalink   string `yaml:"permalink"`
-	Description string `yaml:"description"`
-	Author      string `yaml:"author"`
-	AuthorLink  string `yaml:"author_link"`
-	Date        string `yaml:"date"`
-	Categories  string `yaml:"categories"`
-	Body        string `yaml:"body"`
-	Url         string `yaml:"url"`
+	Layout      string     `yaml:"layout"`
+	Title       string     `yaml:"title"`
+	Permalink   string     `yaml:"permalink"`
+	Description string     `yaml:"description"`
+	Author      string     `yaml:"author"`
+	AuthorLink  string     `yaml:"author_link"`
+	Date        string     `yaml:"date"`
+	Categories  StringList `yaml:"categories"`
+	Tags        StringList `yaml:"tags"`
+	Body        string     `yaml:"body"`
+	Url         string     `yaml:"url"`
+	Paginate    int        `yaml:"paginate"`
+	Path        string     `yaml:"-"`
+	BodyLine    int        `yaml:"-"`
+
+	Time        time.Time              `yaml:"-"`
+	ModTime     time.Time              `yaml:"-"`
+	FrontMatter map[string]interface{} `yaml:"-"`
+	Paginator   map[string]interface{} `yaml:"-"`
+	Excerpt     string                 `yaml:"-"`
+	ExcerptText string                 `yaml:"-"`
+}
+
+// StringList is a list of strings that can be set in YAML either as a list or as a space-separated string
+type StringList []string
+
+func (l *StringList) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var list []string
+	if err := unmarshal(&list); err == nil {
+		*l = list
+		return nil
+	}
+
+	var s string
+	if err := unmarshal(&s); err != nil {
+		return err
+	}
+	*l = strings.Fields(s)
+	return nil
 }
 
 func (p *Page) SetFromFile(fpath string) error {
@@ -29,20 +73,34 @@ func (p *Page) SetFromFile(fpath string) error {
 	if err != nil {
 		return fmt.Errorf("Error opening file %s: %w", fpath, err)
 	}
+	fileInfo, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("Error getting file info for %s: %w", fpath, err)
+	}
+	p.ModTime = fileInfo.ModTime()
+
 	fscan := bufio.NewScanner(f)
 	fscan.Split(bufio.ScanLines)
 
 	foundHeader := false
 	gotHeader := false
 	header := ""
+	headerLine := 1
 	body := ""
+	lineNum := 0
 	for fscan.Scan() {
-		if fscan.Text() == "---" {
+		lineNum++
+		if fscan.Text() == "---" && !gotHeader {
 			if foundHeader {
 				gotHeader = true
+				p.BodyLine = lineNum + 1
 				continue
 			} else {
 				foundHeader = true
+				if header == "" {
+					headerLine = lineNum + 1
+				}
 				continue
 			}
 		}
@@ -64,14 +122,113 @@ func (p *Page) SetFromFile(fpath string) error {
 	}
 
 	p.Body = body
+	p.Path = fpath
+
+	// string fields with a value of another type, eg. a list in description, are skipped here and kept in
+	// front matter
+	err = yaml.Unmarshal([]byte(header), p)
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		err = getFrontMatterTypeError(typeErr, header)
+	}
+	if err != nil {
+		return &SourceError{
+			Path: fpath,
+			Line: getYamlErrorLine(err, headerLine),
+			Err:  fmt.Errorf("Error setting page from YAML: %w", err),
+		}
+	}
 
-	if err := yaml.Unmarshal([]byte(header), p); err != nil {
-		return fmt.Errorf("Error setting page from YAML: %w", err)
+	frontMatter := map[string]interface{}{}
+	if err := yaml.Unmarshal([]byte(header), &frontMatter); err != nil {
+		return &SourceError{
+			Path: fpath,
+			Line: getYamlErrorLine(err, headerLine),
+			Err:  fmt.Errorf("Error getting front matter from YAML: %w", err),
+		}
 	}
+	p.FrontMatter = normalizeYamlValue(frontMatter).(map[string]interface{})
 
 	return nil
 }
 
+// getFrontMatterTypeError returns the first type error in front matter that is not in a string field of Page,
+// eg. paginate: "10", or nil when all of them are
+func getFrontMatterTypeError(typeErr *yaml.TypeError, header string) error {
+	stringFields := map[string]bool{}
+	t := reflect.TypeOf(Page{})
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tagVal := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if field.Type.Kind() == reflect.String && tagVal != "" && tagVal != "-" {
+			stringFields[tagVal] = true
+		}
+	}
+
+	// error only has a line, which is the line of the value, so the field is the closest key above it
+	lines := strings.Split(header, "\n")
+	re := regexp.MustCompile(`^line ([0-9]+): cannot unmarshal .* into string$`)
+	keyRe := regexp.MustCompile(`^([a-zA-Z0-9\-\_]+)[ ]*:`)
+	for _, msg := range typeErr.Errors {
+		key := ""
+		if found := re.FindStringSubmatch(msg); found != nil {
+			line, _ := strconv.Atoi(found[1])
+			for i := line - 1; i >= 0 && i < len(lines); i-- {
+				if foundKey := keyRe.FindStringSubmatch(lines[i]); foundKey != nil {
+					key = foundKey[1]
+					break
+				}
+			}
+		}
+		if !stringFields[key] {
+			return errors.New(msg)
+		}
+	}
+	return nil
+}
+
 func (p *Page) Validate() error {
 	return nil
 }
+
+func (p *Page) GetCategories() []string {
+	return []string(p.Categories)
+}
+
+func (p *Page) GetTags() []string {
+	return []string(p.Tags)
+}
+
+func (p *Page) SetTime() error {
+	if p.Date != "" {
+		t, err := parseDate(p.Date)
+		if err != nil {
+			return err
+		}
+		p.Time = t
+		return nil
+	}
+
+	re := regexp.MustCompile(`^([0-9]{4}-[0-9]{2}-[0-9]{2})-`)
+	found := re.FindStringSubmatch(p.Name)
+	if len(found) != 2 {
+		return nil
+	}
+	t, err := time.Parse("2006-01-02", found[1])
+	if err != nil {
+		return fmt.Errorf("Invalid date in filename %s: %w", p.Name, err)
+	}
+	p.Time = t
+
+	return nil
+}
+
+func parseDate(s string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		t, err := time.Parse(layout, strings.Trim(s, " "))
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Invalid date %s", s)
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+)
+
+// setPaginators sets paginator of every page with paginate set and returns pages for the following
+// paginator pages.  Posts are taken from page.posts when the page has them (category and tag pages)
+// and from site posts otherwise.  Urls of posts and pages must be set before calling it.
+func (g *Generator) setPaginators(w *Website, pages []*Page) ([]*Page, error) {
+	sitePosts := []interface{}{}
+	for _, name := range w.PostsNames {
+		sitePosts = append(sitePosts, g.getPageVariables(w.Posts[name]))
+	}
+
+	paginated := []*Page{}
+	for _, p := range pages {
+		if p.Paginate < 0 {
+			return nil, fmt.Errorf("Page %s has invalid paginate value of %d", p.Name, p.Paginate)
+		}
+		if p.Paginate == 0 {
+			continue
+		}
+
+		posts := sitePosts
+		if v, ok := p.FrontMatter["posts"].([]interface{}); ok {
+			posts = v
+		}
+
+		totalPages := (len(posts) + p.Paginate - 1) / p.Paginate
+		if totalPages == 0 {
+			totalPages = 1
+		}
+
+		for i := 1; i <= totalPages; i++ {
+			from := (i - 1) * p.Paginate
+			to := from + p.Paginate
+			if to > len(posts) {
+				to = len(posts)
+			}
+
+			paginator := map[string]interface{}{
+				"page":               i,
+				"per_page":           p.Paginate,
+				"posts":              posts[from:to],
+				"total_posts":        len(posts),
+				"total_pages":        totalPages,
+				"previous_page":      nil,
+				"previous_page_path": nil,
+				"next_page":          nil,
+				"next_page_path":     nil,
+			}
+			if i > 1 {
+				paginator["previous_page"] = i - 1
+				paginator["previous_page_path"] = g.getPaginatorPageUrl(p.Url, i-1)
+			}
+			if i < totalPages {
+				paginator["next_page"] = i + 1
+				paginator["next_page_path"] = g.getPaginatorPageUrl(p.Url, i+1)
+			}
+
+			if i == 1 {
+				p.Paginator = paginator
+				continue
+			}
+
+			page := *p
+			page.Name = fmt.Sprintf("%s page %d", p.Name, i)
+			page.Url = g.getPaginatorPageUrl(p.Url, i)
+			page.Paginator = paginator
+			paginated = append(paginated, &page)
+		}
+	}
+
+	return paginated, nil
+}
+
+// getPaginatorPageUrl returns url of paginator page, eg. /page/2/ for / or /blog/page/2/ for /blog.html
+func (g *Generator) getPaginatorPageUrl(url string, i int) string {
+	if i == 1 {
+		return url
+	}
+	return fmt.Sprintf("%spage/%d/", g.getUrlDir(url), i)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestSetPaginators(t *testing.T) {
+	w := &Website{
+		Posts: map[string]*Page{
+			"2023-03-01-three": &Page{Title: "Three", Url: "/three/"},
+			"2023-02-01-two":   &Page{Title: "Two", Url: "/two/"},
+			"2023-01-01-one":   &Page{Title: "One", Url: "/one/"},
+		},
+		PostsNames: []string{"2023-03-01-three", "2023-02-01-two", "2023-01-01-one"},
+	}
+	index := &Page{Name: "index", Url: "/", Paginate: 2}
+	blog := &Page{Name: "blog", Url: "/blog.html", Paginate: 1, FrontMatter: map[string]interface{}{
+		"posts": []interface{}{map[string]interface{}{"title": "Other"}},
+	}}
+	about := &Page{Name: "about", Url: "/about/"}
+	g := &Generator{}
+
+	pages, err := g.setPaginators(w, []*Page{index, blog, about})
+	if err != nil {
+		t.Fatalf("setPaginators returned error: %s", err.Error())
+	}
+	if len(pages) != 1 || pages[0].Url != "/page/2/" {
+		t.Fatalf("setPaginators returned invalid pages: %v", pages)
+	}
+	if about.Paginator != nil || blog.Paginator["total_pages"] != 1 || blog.Paginator["next_page_path"] != nil {
+		t.Fatalf("setPaginators set invalid paginators")
+	}
+
+	n := &Node{
+		Type: "root",
+		Values: map[string]interface{}{
+			"paginator": index.Paginator,
+		},
+	}
+	n.SetFromString("{{ paginator.page }}/{{ paginator.total_pages }}:{% for post in paginator.posts %}{{ post.title }};{% endfor %}"+
+		"{{ paginator.next_page_path }}", '{', '}', '%')
+	n.ProcessForTags("{%", "%}", &Website{}, g)
+	n.ProcessPostVars()
+	if s := n.GetRaw("{%", "%}"); s != "1/2:Three;Two;/page/2/" {
+		t.Fatalf("setPaginators set invalid paginator on first page: %s", s)
+	}
+
+	n = &Node{
+		Type: "root",
+		Values: map[string]interface{}{
+			"paginator": pages[0].Paginator,
+		},
+	}
+	n.SetFromString("{{ paginator.page }}:{% for post in paginator.posts %}{{ post.title }};{% endfor %}"+
+		"{{ paginator.previous_page_path }}{{ paginator.next_page_path }}", '{', '}', '%')
+	n.ProcessForTags("{%", "%}", &Website{}, g)
+	n.ProcessPostVars()
+	if s := n.GetRaw("{%", "%}"); s != "2:One;/" {
+		t.Fatalf("setPaginators set invalid paginator on second page: %s", s)
+	}
+}
+
+func TestGetPaginatorPageUrl(t *testing.T) {
+	g := &Generator{}
+	urls := map[string]string{
+		"/":                 "/page/3/",
+		"/category/golang/": "/category/golang/page/3/",
+		"/blog.html":        "/blog/page/3/",
+	}
+	for url, expected := range urls {
+		if s := g.getPaginatorPageUrl(url, 3); s != expected {
+			t.Fatalf("getPaginatorPageUrl returned %s instead of %s", s, expected)
+		}
+	}
+	if s := g.getPaginatorPageUrl("/blog.html", 1); s != "/blog.html" {
+		t.Fatalf("getPaginatorPageUrl returned %s for the first page", s)
+	}
+}
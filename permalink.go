@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+var permalinkStyles = map[string]string{
+	"pretty": "/:categories/:year/:month/:day/:title/",
+	"date":   "/:categories/:year/:month/:day/:title:output_ext",
+	"none":   "/:categories/:title:output_ext",
+}
+
+func (g *Generator) setUrls(w *Website) error {
+	paths := map[string]string{}
+
+	for _, name := range w.PostsNames {
+		post := w.Posts[name]
+		url, err := g.getPostUrl(name, post, w)
+		if err != nil {
+			return fmt.Errorf("Error getting post %s url: %w", name, err)
+		}
+		if other, ok := paths[url]; ok {
+			return fmt.Errorf("Post %s has the same url %s as %s", name, url, other)
+		}
+		paths[url] = name
+		post.Url = url
+	}
+
+	for _, name := range w.PageNames {
+		page := w.Pages[name]
+		url, err := g.getPageUrl(name, page)
+		if err != nil {
+			return fmt.Errorf("Error getting page %s url: %w", name, err)
+		}
+		if other, ok := paths[url]; ok {
+			return fmt.Errorf("Page %s has the same url %s as %s", name, url, other)
+		}
+		paths[url] = name
+		page.Url = url
+	}
+
+	return nil
+}
+
+// setExtraPages sets urls of category, tag and paginator pages.  Post variables are copied into them, so it
+// must be called after excerpts are set.
+func (g *Generator) setExtraPages(w *Website) error {
+	paths := map[string]string{}
+	for _, name := range w.PostsNames {
+		paths[w.Posts[name].Url] = name
+	}
+	for _, name := range w.PageNames {
+		paths[w.Pages[name].Url] = name
+	}
+
+	taxonomyPages, err := g.getTaxonomyPages(w)
+	if err != nil {
+		return err
+	}
+	for _, page := range taxonomyPages {
+		if other, ok := paths[page.Url]; ok {
+			return fmt.Errorf("Page for %s has the same url %s as %s", page.Name, page.Url, other)
+		}
+		paths[page.Url] = page.Name
+	}
+	g.taxonomyPages = taxonomyPages
+
+	pages := []*Page{}
+	for _, name := range w.PageNames {
+		pages = append(pages, w.Pages[name])
+	}
+	paginatedPages, err := g.setPaginators(w, append(pages, taxonomyPages...))
+	if err != nil {
+		return err
+	}
+	for _, page := range paginatedPages {
+		if other, ok := paths[page.Url]; ok {
+			return fmt.Errorf("Page %s has the same url %s as %s", page.Name, page.Url, other)
+		}
+		paths[page.Url] = page.Name
+	}
+	g.paginatedPages = paginatedPages
+
+	return nil
+}
+
+func (g *Generator) getPostUrl(name string, p *Page, w *Website) (string, error) {
+	re := regexp.MustCompile(`^([0-9]{4})-([01][0-9])-([0-3][0-9])-([a-zA-Z0-9\_\-]+)$`)
+	if !re.MatchString(name) {
+		return "", fmt.Errorf("Post %s filename does not match regexp", name)
+	}
+	nameArr := re.FindStringSubmatch(name)
+
+	categories := []string{}
+	re = regexp.MustCompile(`^[a-zA-Z0-9\_\-]+$`)
+	for _, c := range p.GetCategories() {
+		if !re.MatchString(c) {
+			categories = []string{}
+			break
+		}
+		categories = append(categories, c)
+	}
+	if len(categories) == 0 {
+		categories = append(categories, "posts")
+	}
+
+	pattern := p.Permalink
+	if pattern == "" {
+		pattern = w.Config.Permalink
+	}
+	if pattern == "" {
+		pattern = "pretty"
+	}
+	if permalinkStyles[pattern] != "" {
+		pattern = permalinkStyles[pattern]
+	}
+
+	slug := g.slugify(p.Title)
+	if slug == "" {
+		slug = nameArr[4]
+	}
+
+	return g.expandPermalink(pattern, map[string]string{
+		"categories": strings.Join(categories, "/"),
+		"year":       nameArr[1],
+		"month":      nameArr[2],
+		"day":        nameArr[3],
+		"title":      nameArr[4],
+		"slug":       slug,
+		"name":       name,
+	})
+}
+
+func (g *Generator) getPageUrl(name string, p *Page) (string, error) {
+	pattern := p.Permalink
+	if pattern == "" {
+		switch name {
+		case "index":
+			return "/", nil
+		case "404":
+			return "/404.html", nil
+		default:
+			pattern = "/:title/"
+		}
+	}
+
+	slug := g.slugify(p.Title)
+	if slug == "" {
+		slug = name
+	}
+
+	return g.expandPermalink(pattern, map[string]string{
+		"categories": "",
+		"title":      name,
+		"slug":       slug,
+		"name":       name,
+	})
+}
+
+func (g *Generator) expandPermalink(pattern string, vars map[string]string) (string, error) {
+	var err error
+	re := regexp.MustCompile(`:([a-z_]+)`)
+	url := re.ReplaceAllStringFunc(pattern, func(s string) string {
+		name := s[1:]
+		if name == "output_ext" {
+			return ".html"
+		}
+		v, ok := vars[name]
+		if !ok {
+			err = fmt.Errorf("Unknown permalink placeholder %s", s)
+			return ""
+		}
+		return v
+	})
+	if err != nil {
+		return "", err
+	}
+
+	trailingSlash := strings.HasSuffix(url, "/")
+	url = path.Clean("/" + url)
+	if url != "/" && (trailingSlash || path.Ext(url) == "") {
+		url += "/"
+	}
+
+	return url, nil
+}
+
+// getUrlDir returns url of directory for files related to the url, eg. /blog/ for both /blog/ and /blog.html
+func (g *Generator) getUrlDir(url string) string {
+	if strings.HasSuffix(url, "/") {
+		return url
+	}
+	return strings.TrimSuffix(url, path.Ext(url)) + "/"
+}
+
+// getAbsoluteUrl returns url prefixed with url and baseurl from the config
+func (g *Generator) getAbsoluteUrl(url string, w *Website) string {
+	base := strings.TrimSuffix(w.Config.Url, "/")
+	if baseurl := strings.Trim(w.Config.Baseurl, "/"); baseurl != "" {
+		base += "/" + baseurl
+	}
+	return base + url
+}
+
+func (g *Generator) getUrlFilePath(url string) string {
+	p := filepath.FromSlash(strings.TrimPrefix(url, "/"))
+	if url == "" || strings.HasSuffix(url, "/") {
+		p = filepath.Join(p, "index.html")
+	}
+	return p
+}
+
+func (g *Generator) slugify(s string) string {
+	re := regexp.MustCompile(`[^a-z0-9]+`)
+	return strings.Trim(re.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SourceError is an error at a position in a source file, eg. an invalid tag in a layout
+type SourceError struct {
+	Path    string
+	Line    int
+	Col     int
+	Snippet string
+	// Chain contains files that the one with the error was included through, starting from the outermost one
+	Chain []string
+	Err   error
+}
+
+func (e *SourceError) Error() string {
+	s := e.Path
+	if e.Line > 0 {
+		s += fmt.Sprintf(":%d", e.Line)
+		if e.Col > 0 {
+			s += fmt.Sprintf(":%d", e.Col)
+		}
+	}
+	s += ": " + e.Err.Error()
+	if e.Snippet != "" {
+		s += fmt.Sprintf(" in '%s'", e.Snippet)
+	}
+	if len(e.Chain) > 1 {
+		s += fmt.Sprintf(" (%s)", strings.Join(e.Chain, " -> "))
+	}
+	return s
+}
+
+func (e *SourceError) Unwrap() error {
+	return e.Err
+}
+
+// annotateTagPositions adds line and column of every tag to the tag itself, eg. {% if %} in the second line
+// becomes {%@2:1 if %}, so that positions are known after the contents went through markdown and loops.
+// firstLine is the line number of the first line of s in its file.
+func annotateTagPositions(s string, firstLine int) string {
+	re := regexp.MustCompile(`\{%|\{\{`)
+	var out strings.Builder
+	line := firstLine
+	// col is the number of runes between the start of the line and last
+	col := 0
+	last := 0
+	for _, found := range re.FindAllStringIndex(s, -1) {
+		for _, r := range s[last:found[0]] {
+			if r == '\n' {
+				line++
+				col = 0
+			} else {
+				col++
+			}
+		}
+		out.WriteString(s[last:found[1]])
+		out.WriteString(fmt.Sprintf("@%d:%d", line, col+1))
+		col += found[1] - found[0]
+		last = found[1]
+	}
+	out.WriteString(s[last:])
+	return out.String()
+}
+
+// stripTagPositions removes positions added by annotateTagPositions
+func stripTagPositions(s string) string {
+	re := regexp.MustCompile(`(\{%|\{\{)@[0-9]+:[0-9]+`)
+	return re.ReplaceAllString(s, "$1")
+}
+
+// parseTagPosition returns line and column annotated in tag contents, and the contents without them
+func parseTagPosition(s string) (int, int, string) {
+	re := regexp.MustCompile(`^@([0-9]+):([0-9]+)`)
+	found := re.FindStringSubmatch(s)
+	if found == nil {
+		return 0, 0, s
+	}
+	line, _ := strconv.Atoi(found[1])
+	col, _ := strconv.Atoi(found[2])
+	return line, col, s[len(found[0]):]
+}
+
+// getYamlErrorLine returns line number of YAML error in a file, where YAML starts at firstLine
+func getYamlErrorLine(err error, firstLine int) int {
+	re := regexp.MustCompile(`line ([0-9]+)`)
+	found := re.FindStringSubmatch(err.Error())
+	if found == nil {
+		return 0
+	}
+	line, _ := strconv.Atoi(found[1])
+	return firstLine + line - 1
+}
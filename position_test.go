@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAnnotateTagPositions(t *testing.T) {
+	s := "<p>{{ page.title }}</p>\n  {% if x %}ż{{ x }}{% endif %}"
+	annotated := annotateTagPositions(s, 3)
+	expected := "<p>{{@3:4 page.title }}</p>\n  {%@4:3 if x %}ż{{@4:14 x }}{%@4:21 endif %}"
+	if annotated != expected {
+		t.Fatalf("annotateTagPositions returned %s instead of %s", annotated, expected)
+	}
+	if stripped := stripTagPositions(annotated); stripped != s {
+		t.Fatalf("stripTagPositions returned %s instead of %s", stripped, s)
+	}
+
+	line, col, rest := parseTagPosition("@4:14 x ")
+	if line != 4 || col != 14 || rest != " x " {
+		t.Fatalf("parseTagPosition returned %d, %d, %s instead of 4, 14,  x ", line, col, rest)
+	}
+}
+
+func TestSourceErrorPosition(t *testing.T) {
+	w := &Website{
+		Config: &Config{},
+		Includes: map[string]*Include{
+			"menu": &Include{Name: "menu", ContentType: "html", Path: "src/_includes/menu.html",
+				Body: "<ul>\n  {% for item in %}{% endfor %}</ul>"},
+		},
+	}
+
+	n := &Node{Type: "root", Source: "src/_layouts/default.html"}
+	n.SetFromString(annotateTagPositions("<body>\n{% include menu.html %}", 1), '{', '}', '%')
+	err := n.ProcessIncludeTags("{%", "%}", w, &Generator{}, nil, nil)
+
+	var sourceErr *SourceError
+	if !errors.As(err, &sourceErr) {
+		t.Fatalf("ProcessIncludeTags did not return SourceError: %v", err)
+	}
+	expected := "src/_includes/menu.html:2:3: Invalid for tag in '{% for item in %}' " +
+		"(src/_layouts/default.html -> src/_includes/menu.html)"
+	if err.Error() != expected {
+		t.Fatalf("ProcessIncludeTags returned error %s instead of %s", err.Error(), expected)
+	}
+}
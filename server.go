@@ -0,0 +1,343 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const liveReloadPath = "/__spidey/events"
+
+const liveReloadScript = `<script>
+(function() {
+	var es = new EventSource("` + liveReloadPath + `");
+	es.addEventListener("reload", function() {
+		window.location.reload();
+	});
+	es.addEventListener("builderror", function(e) {
+		var el = document.getElementById("spidey-build-error");
+		if (!el) {
+			el = document.createElement("pre");
+			el.id = "spidey-build-error";
+			el.style.cssText = "position:fixed;top:0;left:0;right:0;margin:0;padding:1em;background:#a00;color:#fff;white-space:pre-wrap;z-index:99999";
+			document.body.appendChild(el);
+		}
+		el.textContent = JSON.parse(e.data);
+	});
+})();
+</script>
+`
+
+type Server struct {
+	SourcePath string
+	Address    string
+	Watch      bool
+	Drafts     bool
+	Future     bool
+	Strict     bool
+
+	mu              sync.RWMutex
+	destinationPath string
+	buildErr        error
+	clients         map[chan [2]string]bool
+	done            chan struct{}
+}
+
+func (s *Server) Run() error {
+	s.clients = map[chan [2]string]bool{}
+	s.done = make(chan struct{})
+
+	defer func() {
+		s.mu.Lock()
+		if s.destinationPath != "" {
+			os.RemoveAll(s.destinationPath)
+		}
+		s.mu.Unlock()
+	}()
+
+	if err := s.rebuild(); err != nil && !s.Watch {
+		return err
+	}
+
+	httpServer := &http.Server{
+		Addr:    s.Address,
+		Handler: s,
+	}
+	httpServer.RegisterOnShutdown(func() {
+		close(s.done)
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- httpServer.ListenAndServe()
+	}()
+
+	fmt.Fprintf(os.Stdout, "Serving %s on %s\n", s.SourcePath, s.getUrl())
+
+	if s.Watch {
+		stopWatching := make(chan struct{})
+		defer close(stopWatching)
+
+		watcher := &Watcher{
+			Path: s.SourcePath,
+		}
+		go func() {
+			err := watcher.Watch(stopWatching, func() {
+				fmt.Fprintf(os.Stdout, "Change detected, rebuilding...\n")
+				s.rebuild()
+			})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "!!!! Error with watching: %s\n", err.Error())
+			}
+		}()
+	}
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("Error listening on %s: %w", s.Address, err)
+		}
+	case <-stop:
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := httpServer.Shutdown(ctx); err != nil {
+			return fmt.Errorf("Error shutting down server: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.Watch && r.URL.Path == liveReloadPath {
+		s.serveEvents(w, r)
+		return
+	}
+
+	s.mu.RLock()
+	destinationPath := s.destinationPath
+	buildErr := s.buildErr
+	s.mu.RUnlock()
+
+	urlPath := path.Clean("/" + r.URL.Path)
+
+	// assets are still served from the last successful build so that only pages are replaced with the error
+	if buildErr != nil && isPageRequest(urlPath) {
+		s.serveBuildError(w, buildErr)
+		return
+	}
+	// when the first build failed there is nothing to serve and joining an empty path would expose files
+	// from the working directory and the filesystem root
+	if destinationPath == "" {
+		http.NotFound(w, r)
+		return
+	}
+	filePath := filepath.Join(destinationPath, filepath.FromSlash(urlPath))
+
+	fileInfo, err := os.Stat(filePath)
+	if err != nil {
+		s.serveNotFound(w, r, destinationPath)
+		return
+	}
+
+	if fileInfo.IsDir() {
+		if !strings.HasSuffix(r.URL.Path, "/") {
+			http.Redirect(w, r, r.URL.Path+"/", http.StatusMovedPermanently)
+			return
+		}
+		filePath = filepath.Join(filePath, "index.html")
+		fileInfo, err = os.Stat(filePath)
+		if err != nil || !fileInfo.Mode().IsRegular() {
+			s.serveNotFound(w, r, destinationPath)
+			return
+		}
+	}
+
+	if s.Watch && strings.HasSuffix(filePath, ".html") {
+		b, err := os.ReadFile(filePath)
+		if err != nil {
+			s.serveNotFound(w, r, destinationPath)
+			return
+		}
+		http.ServeContent(w, r, filePath, fileInfo.ModTime(), bytes.NewReader(s.injectLiveReload(b)))
+		return
+	}
+
+	http.ServeFile(w, r, filePath)
+}
+
+// isPageRequest returns true when url path points to an HTML page rather than an asset, eg. /about/ or
+// /index.html but not /assets/style.css
+func isPageRequest(urlPath string) bool {
+	ext := path.Ext(urlPath)
+	return ext == "" || ext == ".html" || ext == ".htm"
+}
+
+func (s *Server) serveNotFound(w http.ResponseWriter, r *http.Request, destinationPath string) {
+	b, err := os.ReadFile(filepath.Join(destinationPath, "404.html"))
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	if s.Watch {
+		b = s.injectLiveReload(b)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusNotFound)
+	w.Write(b)
+}
+
+func (s *Server) serveBuildError(w http.ResponseWriter, buildErr error) {
+	b := []byte(fmt.Sprintf("<!DOCTYPE html>\n<html><head><title>Build error</title></head><body><h1>Build error</h1><pre>%s</pre></body></html>\n", html.EscapeString(buildErr.Error())))
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write(s.injectLiveReload(b))
+}
+
+func (s *Server) serveEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := make(chan [2]string, 1)
+	s.mu.Lock()
+	s.clients[ch] = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.clients, ch)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		case ev := <-ch:
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev[0], ev[1])
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *Server) notifyClients(event string, data string) {
+	b, _ := json.Marshal(data)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for ch := range s.clients {
+		select {
+		case ch <- [2]string{event, string(b)}:
+		default:
+		}
+	}
+}
+
+func (s *Server) injectLiveReload(b []byte) []byte {
+	i := bytes.LastIndex(b, []byte("</body>"))
+	if i == -1 {
+		return append(b, []byte(liveReloadScript)...)
+	}
+	out := make([]byte, 0, len(b)+len(liveReloadScript))
+	out = append(out, b[:i]...)
+	out = append(out, []byte(liveReloadScript)...)
+	return append(out, b[i:]...)
+}
+
+func (s *Server) rebuild() error {
+	dir, err := os.MkdirTemp("", "spidey-")
+	if err != nil {
+		return fmt.Errorf("Error creating temporary destination directory: %w", err)
+	}
+
+	if err := s.build(dir); err != nil {
+		os.RemoveAll(dir)
+
+		s.mu.Lock()
+		s.buildErr = err
+		s.mu.Unlock()
+
+		fmt.Fprintf(os.Stderr, "!!!! %s\n", err.Error())
+		s.notifyClients("builderror", err.Error())
+		return err
+	}
+
+	s.mu.Lock()
+	oldPath := s.destinationPath
+	s.destinationPath = dir
+	s.buildErr = nil
+	s.mu.Unlock()
+
+	if oldPath != "" {
+		os.RemoveAll(oldPath)
+	}
+
+	s.notifyClients("reload", "")
+	return nil
+}
+
+func (s *Server) build(destinationPath string) error {
+	website := &Website{
+		SourcePath: s.SourcePath,
+		Drafts:     s.Drafts,
+		Future:     s.Future,
+	}
+
+	if err := website.Init(); err != nil {
+		return fmt.Errorf("Error with website initialization: %w", err)
+	}
+
+	website.Config.Url = s.getUrl()
+	website.Config.Baseurl = ""
+
+	gen := &Generator{
+		DestinationPath: destinationPath,
+		Strict:          s.Strict,
+	}
+
+	if err := gen.Generate(website); err != nil {
+		return fmt.Errorf("Error with generation: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Server) getUrl() string {
+	host, port, err := net.SplitHostPort(s.Address)
+	if err != nil {
+		return "http://" + s.Address
+	}
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "localhost"
+	}
+	return "http://" + net.JoinHostPort(host, port)
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestServeHTTP(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "about"), 0750)
+	os.WriteFile(filepath.Join(dir, "index.html"), []byte("Index!"), 0640)
+	os.WriteFile(filepath.Join(dir, "about", "index.html"), []byte("About!"), 0640)
+	os.WriteFile(filepath.Join(dir, "404.html"), []byte("NotFound!"), 0640)
+
+	s := &Server{
+		destinationPath: dir,
+	}
+
+	for _, tc := range []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/", http.StatusOK, "Index!"},
+		{"/about/", http.StatusOK, "About!"},
+		{"/about", http.StatusMovedPermanently, ""},
+		{"/missing/", http.StatusNotFound, "NotFound!"},
+		{"/../../etc/passwd", http.StatusNotFound, "NotFound!"},
+	} {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest("GET", tc.path, nil))
+		if rec.Code != tc.code {
+			t.Fatalf("ServeHTTP returned %d instead of %d for %s", rec.Code, tc.code, tc.path)
+		}
+		if tc.body != "" && rec.Body.String() != tc.body {
+			t.Fatalf("ServeHTTP returned invalid body for %s", tc.path)
+		}
+	}
+}
+
+func TestServerGetUrl(t *testing.T) {
+	for addr, url := range map[string]string{
+		"localhost:8080": "http://localhost:8080",
+		":8080":          "http://localhost:8080",
+		"0.0.0.0:1234":   "http://localhost:1234",
+	} {
+		s := &Server{Address: addr}
+		if s.getUrl() != url {
+			t.Fatalf("getUrl returned %s instead of %s", s.getUrl(), url)
+		}
+	}
+}
+
+func TestInjectLiveReload(t *testing.T) {
+	s := &Server{}
+	b := string(s.injectLiveReload([]byte("<html><body>Page!</body></html>")))
+	if b != "<html><body>Page!"+liveReloadScript+"</body></html>" {
+		t.Fatalf("injectLiveReload failed to inject script before closing body tag")
+	}
+	b = string(s.injectLiveReload([]byte("Page!")))
+	if b != "Page!"+liveReloadScript {
+		t.Fatalf("injectLiveReload failed to append script")
+	}
+}
+
+func TestServeHTTPBuildError(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "index.html"), []byte("Index!"), 0640)
+	os.WriteFile(filepath.Join(dir, "style.css"), []byte("body {}"), 0640)
+
+	s := &Server{
+		destinationPath: dir,
+		buildErr:        errors.New("Layout missing does not exist"),
+	}
+
+	for _, tc := range []struct {
+		path string
+		code int
+	}{
+		{"/", http.StatusInternalServerError},
+		{"/index.html", http.StatusInternalServerError},
+		{"/style.css", http.StatusOK},
+	} {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest("GET", tc.path, nil))
+		if rec.Code != tc.code {
+			t.Fatalf("ServeHTTP returned %d instead of %d for %s", rec.Code, tc.code, tc.path)
+		}
+	}
+
+	s = &Server{
+		buildErr: errors.New("Layout missing does not exist"),
+	}
+	for _, tc := range []struct {
+		path string
+		code int
+	}{
+		{"/", http.StatusInternalServerError},
+		{"/etc/passwd", http.StatusInternalServerError},
+		{"/etc/ssl/openssl.cnf", http.StatusNotFound},
+		{"/go.mod", http.StatusNotFound},
+	} {
+		rec := httptest.NewRecorder()
+		s.ServeHTTP(rec, httptest.NewRequest("GET", tc.path, nil))
+		if rec.Code != tc.code {
+			t.Fatalf("ServeHTTP returned %d instead of %d for %s without destination", rec.Code, tc.code, tc.path)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+type sitemapUrlSet struct {
+	XMLName xml.Name     `xml:"http://www.sitemaps.org/schemas/sitemap/0.9 urlset"`
+	Urls    []sitemapUrl `xml:"url"`
+}
+
+type sitemapUrl struct {
+	Loc     string `xml:"loc"`
+	Lastmod string `xml:"lastmod,omitempty"`
+}
+
+// generateSitemap writes sitemap.xml with urls of all generated posts and pages, except the ones with
+// sitemap set to false in front matter and the 404 page.  It is not generated when the source directory
+// has its own sitemap.xml.
+func (g *Generator) generateSitemap(w *Website) error {
+	if g.hasSourceFile("/sitemap.xml", w) {
+		return nil
+	}
+
+	pages := []*Page{}
+	for _, name := range w.PostsNames {
+		pages = append(pages, w.Posts[name])
+	}
+	for _, name := range w.PageNames {
+		pages = append(pages, w.Pages[name])
+	}
+	pages = append(pages, g.taxonomyPages...)
+	pages = append(pages, g.paginatedPages...)
+
+	urlSet := &sitemapUrlSet{
+		Urls: []sitemapUrl{},
+	}
+	for _, p := range pages {
+		if v, ok := p.FrontMatter["sitemap"]; ok && !isTruthy(v) {
+			continue
+		}
+		if p.Url == "/404.html" {
+			continue
+		}
+
+		lastmod := p.Time
+		if t, ok := valueToTime(p.FrontMatter["last_modified_at"]); ok {
+			lastmod = t
+		}
+		if lastmod.IsZero() {
+			lastmod = p.ModTime
+		}
+
+		u := sitemapUrl{
+			Loc: g.getAbsoluteUrl(p.Url, w),
+		}
+		if !lastmod.IsZero() {
+			u.Lastmod = lastmod.Format(time.RFC3339)
+		}
+		urlSet.Urls = append(urlSet.Urls, u)
+	}
+
+	b, err := xml.MarshalIndent(urlSet, "", "  ")
+	if err != nil {
+		return fmt.Errorf("Error generating sitemap.xml: %w", err)
+	}
+
+	if err := g.writeUrlFile("/sitemap.xml", xml.Header+string(b)+"\n"); err != nil {
+		return fmt.Errorf("Error writing sitemap.xml: %w", err)
+	}
+	return nil
+}
+
+// generateRobots writes robots.txt that allows everything and points to the sitemap, unless the source
+// directory has its own robots.txt
+func (g *Generator) generateRobots(w *Website) error {
+	if g.hasSourceFile("/robots.txt", w) {
+		return nil
+	}
+
+	robots := fmt.Sprintf("User-agent: *\nAllow: /\n\nSitemap: %s\n", g.getAbsoluteUrl("/sitemap.xml", w))
+	if err := g.writeUrlFile("/robots.txt", robots); err != nil {
+		return fmt.Errorf("Error writing robots.txt: %w", err)
+	}
+	return nil
+}
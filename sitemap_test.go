@@ -0,0 +1,62 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerateSitemap(t *testing.T) {
+	src := t.TempDir()
+	dst := t.TempDir()
+	w := &Website{
+		SourcePath: src,
+		Config: &Config{
+			Url:     "http://example.com",
+			Baseurl: "/blog/",
+		},
+		Posts: map[string]*Page{
+			"2023-01-01-one": &Page{Url: "/one/", Time: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		PostsNames: []string{"2023-01-01-one"},
+		Pages: map[string]*Page{
+			"about":  &Page{Url: "/about/", ModTime: time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+			"secret": &Page{Url: "/secret/", FrontMatter: map[string]interface{}{"sitemap": false}},
+			"404":    &Page{Url: "/404.html"},
+		},
+		PageNames: []string{"about", "secret", "404"},
+	}
+	g := &Generator{
+		DestinationPath: dst,
+	}
+
+	if err := g.generateSitemap(w); err != nil {
+		t.Fatalf("generateSitemap returned error: %s", err.Error())
+	}
+	b, _ := os.ReadFile(filepath.Join(dst, "sitemap.xml"))
+	sitemap := string(b)
+	if !strings.Contains(sitemap, "<loc>http://example.com/blog/one/</loc>\n    <lastmod>2023-01-01T00:00:00Z</lastmod>") ||
+		!strings.Contains(sitemap, "<loc>http://example.com/blog/about/</loc>\n    <lastmod>2024-02-01T00:00:00Z</lastmod>") ||
+		strings.Contains(sitemap, "secret") || strings.Contains(sitemap, "404") {
+		t.Fatalf("generateSitemap generated invalid sitemap.xml: %s", sitemap)
+	}
+
+	if err := g.generateRobots(w); err != nil {
+		t.Fatalf("generateRobots returned error: %s", err.Error())
+	}
+	b, _ = os.ReadFile(filepath.Join(dst, "robots.txt"))
+	if !strings.Contains(string(b), "Sitemap: http://example.com/blog/sitemap.xml\n") {
+		t.Fatalf("generateRobots generated invalid robots.txt: %s", string(b))
+	}
+
+	os.WriteFile(filepath.Join(src, "robots.txt"), []byte("Own robots"), 0640)
+	os.Remove(filepath.Join(dst, "robots.txt"))
+	if err := g.generateRobots(w); err != nil {
+		t.Fatalf("generateRobots returned error: %s", err.Error())
+	}
+	if _, err := os.Stat(filepath.Join(dst, "robots.txt")); !os.IsNotExist(err) {
+		t.Fatalf("generateRobots generated robots.txt when source has its own")
+	}
+}
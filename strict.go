@@ -0,0 +1,115 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// tagPosition is a block tag found in a source file
+type tagPosition struct {
+	Name    string
+	Line    int
+	Col     int
+	Snippet string
+}
+
+// isStrict returns true when undefined variables, unknown filters and unbalanced tags should fail the
+// generation, which is set with the --strict flag or strict option in _config.yml
+func (g *Generator) isStrict(w *Website) bool {
+	return g.Strict || (w.Config != nil && w.Config.Strict)
+}
+
+// addProblem adds a problem found in strict mode while generating page p, skipping ones that were already
+// found, eg. in a loop
+func (g *Generator) addProblem(p *Page, err error) {
+	problem := fmt.Errorf("Page %s: %w", p.Name, err)
+	for _, existing := range g.problems {
+		if existing.Error() == problem.Error() {
+			return
+		}
+	}
+	g.problems = append(g.problems, problem)
+}
+
+// getProblemsError returns one error listing all problems found in strict mode, or nil when there are none
+func (g *Generator) getProblemsError() error {
+	if len(g.problems) == 0 {
+		return nil
+	}
+	return fmt.Errorf("Strict mode found %d problems:\n%w", len(g.problems), errors.Join(g.problems...))
+}
+
+// checkTags checks that if, unless, for and raw tags in all layouts, includes, posts and pages are closed
+// with matching end tags
+func (g *Generator) checkTags(w *Website) {
+	for _, name := range w.LayoutNames {
+		l := w.Layouts[name]
+		g.problems = append(g.problems, checkTagStructure(l.Body, l.Path, l.BodyLine)...)
+	}
+	for _, name := range w.IncludeNames {
+		i := w.Includes[name]
+		g.problems = append(g.problems, checkTagStructure(i.Body, i.Path, 1)...)
+	}
+	for _, name := range w.PostsNames {
+		p := w.Posts[name]
+		g.problems = append(g.problems, checkTagStructure(p.Body, p.Path, p.BodyLine)...)
+	}
+	for _, name := range w.PageNames {
+		p := w.Pages[name]
+		g.problems = append(g.problems, checkTagStructure(p.Body, p.Path, p.BodyLine)...)
+	}
+}
+
+// checkTagStructure returns block tags in s that are not closed, end tags without an opening tag and end tags
+// that close a different tag, eg. {% endfor %} after {% if %}.  Tags inside raw are not checked.
+func checkTagStructure(s string, path string, firstLine int) []error {
+	problems := []error{}
+	newError := func(t tagPosition, err error) error {
+		return &SourceError{Path: path, Line: t.Line, Col: t.Col, Snippet: t.Snippet, Err: err}
+	}
+
+	re := regexp.MustCompile(`(?s)\{%@([0-9]+):([0-9]+)(.*?)%\}`)
+	stack := []tagPosition{}
+	for _, found := range re.FindAllStringSubmatch(annotateTagPositions(s, firstLine), -1) {
+		line, _ := strconv.Atoi(found[1])
+		col, _ := strconv.Atoi(found[2])
+		contents := found[3]
+		fields := strings.Fields(contents)
+		if len(fields) == 0 {
+			continue
+		}
+		t := tagPosition{Name: fields[0], Line: line, Col: col, Snippet: "{%" + contents + "%}"}
+
+		if len(stack) > 0 && stack[len(stack)-1].Name == "raw" && t.Name != "endraw" {
+			continue
+		}
+
+		switch t.Name {
+		case "if", "unless", "for", "raw":
+			stack = append(stack, t)
+		case "elsif", "else":
+			if len(stack) == 0 {
+				problems = append(problems, newError(t, fmt.Errorf("Tag %s is not inside if, unless or for tag", t.Name)))
+			}
+		case "endif", "endunless", "endfor", "endraw":
+			if len(stack) == 0 {
+				problems = append(problems, newError(t, fmt.Errorf("Tag %s has no matching %s tag", t.Name, strings.TrimPrefix(t.Name, "end"))))
+				continue
+			}
+			open := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if t.Name != "end"+open.Name {
+				problems = append(problems, newError(t, fmt.Errorf("Tag %s closes %s tag opened at line %d, expected end%s", t.Name, open.Name, open.Line, open.Name)))
+			}
+		}
+	}
+
+	for _, t := range stack {
+		problems = append(problems, newError(t, fmt.Errorf("Tag %s is not closed", t.Name)))
+	}
+
+	return problems
+}
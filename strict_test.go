@@ -0,0 +1,107 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckTagStructure(t *testing.T) {
+	s := "{% if a %}\n{% for i in b %}{% endif %}\n{% endfor %}{% endunless %}\n" +
+		"{% raw %}{% endif %}{% endraw %}{% else %}{% unless c %}"
+	problems := checkTagStructure(s, "page.html", 3)
+
+	expected := []string{
+		"page.html:4:17: Tag endif closes for tag opened at line 4, expected endfor in '{% endif %}'",
+		"page.html:5:1: Tag endfor closes if tag opened at line 3, expected endif in '{% endfor %}'",
+		"page.html:5:13: Tag endunless has no matching unless tag in '{% endunless %}'",
+		"page.html:6:33: Tag else is not inside if, unless or for tag in '{% else %}'",
+		"page.html:6:43: Tag unless is not closed in '{% unless c %}'",
+	}
+	if len(problems) != len(expected) {
+		t.Fatalf("checkTagStructure returned %d problems instead of %d: %v", len(problems), len(expected), problems)
+	}
+	for i, problem := range problems {
+		if problem.Error() != expected[i] {
+			t.Fatalf("checkTagStructure returned %s instead of %s", problem.Error(), expected[i])
+		}
+	}
+
+	if problems := checkTagStructure("{% if a %}{% else %}{% for i in b %}{% endfor %}{% endif %}", "page.html", 1); len(problems) != 0 {
+		t.Fatalf("checkTagStructure returned problems for valid tags: %v", problems)
+	}
+}
+
+func TestGenerateStrict(t *testing.T) {
+	w := &Website{
+		Config: &Config{},
+		Layouts: map[string]*Layout{
+			"default": &Layout{Name: "default", Path: "_layouts/default.html", BodyLine: 1,
+				Body: "<title>{{ page.title | shout }}</title>\n{{ content }}"},
+		},
+		Includes: map[string]*Include{},
+	}
+	p := &Page{
+		Name:        "about",
+		Title:       "About",
+		Layout:      "default",
+		ContentType: "html",
+		Path:        "about.html",
+		BodyLine:    4,
+		Body: "{{ page.subtitle }}{{ page.subtitle | default: page.title }}{% raw %}{{ page.nope }}{% endraw %}\n" +
+			"{% if page.image %}{{ page.image }}{% endif %}{% for i in (1..2) %}{{ i.name }}{% endfor %}",
+	}
+
+	g := &Generator{Strict: true}
+	g.problems = []error{}
+	g.getSiteVariables(w)
+	g.cachedFilters = g.getFilters(w)
+	if _, err := g.getPageHtml(p, w); err != nil {
+		t.Fatalf("getPageHtml returned error: %s", err.Error())
+	}
+
+	err := g.getProblemsError()
+	if err == nil {
+		t.Fatalf("getProblemsError did not return error")
+	}
+	expected := "Strict mode found 3 problems:\n" +
+		"Page about: about.html:4:1: Undefined variable page.subtitle in '{{ page.subtitle }}'\n" +
+		"Page about: about.html:5:68: Undefined variable i.name in '{{ i.name }}'\n" +
+		"Page about: _layouts/default.html:1:8: Unknown filter shout in '{{ page.title | shout }}'"
+	if err.Error() != expected {
+		t.Fatalf("getProblemsError returned:\n%s\ninstead of:\n%s", err.Error(), expected)
+	}
+
+	g.Strict = false
+	g.problems = []error{}
+	if _, err := g.getPageHtml(p, w); err != nil || g.getProblemsError() != nil {
+		t.Fatalf("getPageHtml found problems when strict mode is off")
+	}
+}
+
+func TestGenerateStrictTagsFirst(t *testing.T) {
+	src := t.TempDir()
+	for name, body := range map[string]string{
+		"_config.yml":                    "title: Site\nstrict: true\n",
+		"_layouts/default.html":          "{{ content }}",
+		"_includes/empty.html":           "",
+		"_posts/2023-01-01-one.markdown": "---\nlayout: default\ntitle: One\n---\nOne\n",
+		"index.html":                     "---\nlayout: default\n---\n{% if page.title %}{{ page.missing }}\n",
+	} {
+		os.MkdirAll(filepath.Dir(filepath.Join(src, name)), 0750)
+		os.WriteFile(filepath.Join(src, name), []byte(body), 0640)
+	}
+
+	w := &Website{SourcePath: src}
+	if err := w.Init(); err != nil {
+		t.Fatalf("Init returned error: %s", err.Error())
+	}
+	err := (&Generator{DestinationPath: t.TempDir()}).Generate(w)
+	if err == nil {
+		t.Fatalf("Generate did not return error")
+	}
+	expected := "Strict mode found 1 problems:\n" + filepath.Join(src, "index.html") + ":4:1: Tag if is not closed in '{% if page.title %}'"
+	if err.Error() != expected {
+		t.Fatalf("Generate returned:\n%s\ninstead of:\n%s", err.Error(), expected)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// taxonomy describes how listing pages are generated for categories or tags of posts
+type taxonomy struct {
+	Kind             string
+	Layout           string
+	Permalink        string
+	Paginate         int
+	DefaultLayout    string
+	DefaultPermalink string
+}
+
+func (g *Generator) getTaxonomies(w *Website) []taxonomy {
+	return []taxonomy{
+		{
+			Kind:             "category",
+			Layout:           w.Config.CategoryLayout,
+			Permalink:        w.Config.CategoryPermalink,
+			Paginate:         w.Config.CategoryPaginate,
+			DefaultLayout:    "category",
+			DefaultPermalink: "/category/:name/",
+		},
+		{
+			Kind:             "tag",
+			Layout:           w.Config.TagLayout,
+			Permalink:        w.Config.TagPermalink,
+			Paginate:         w.Config.TagPaginate,
+			DefaultLayout:    "tag",
+			DefaultPermalink: "/tag/:name/",
+		},
+	}
+}
+
+// getTaxonomyPages returns listing pages for all categories and tags of posts.  Pages are generated only
+// when the layout set in config, or the default one named after the kind, exists.  Urls of posts must be
+// set before calling it.
+func (g *Generator) getTaxonomyPages(w *Website) ([]*Page, error) {
+	pages := []*Page{}
+
+	for _, t := range g.getTaxonomies(w) {
+		layout := t.Layout
+		if layout == "" {
+			layout = t.DefaultLayout
+		}
+		if w.Layouts[layout] == nil {
+			if t.Layout != "" {
+				return nil, fmt.Errorf("Layout %s for %s pages does not exist", layout, t.Kind)
+			}
+			continue
+		}
+
+		names, posts := g.getTaxonomyPosts(t, w)
+		for _, name := range names {
+			url, err := g.getTaxonomyUrl(t, name)
+			if err != nil {
+				return nil, err
+			}
+
+			postsVars := []interface{}{}
+			for _, post := range posts[name] {
+				postsVars = append(postsVars, g.getPageVariables(post))
+			}
+
+			pages = append(pages, &Page{
+				Name:        fmt.Sprintf("%s %s", t.Kind, name),
+				ContentType: "html",
+				Layout:      layout,
+				Title:       name,
+				Url:         url,
+				Paginate:    t.Paginate,
+				ModTime:     posts[name][0].Time,
+				FrontMatter: map[string]interface{}{
+					t.Kind:  name,
+					"posts": postsVars,
+				},
+			})
+		}
+	}
+
+	return pages, nil
+}
+
+// getTaxonomyPosts returns sorted category or tag names and posts for each of them
+func (g *Generator) getTaxonomyPosts(t taxonomy, w *Website) ([]string, map[string][]*Page) {
+	posts := map[string][]*Page{}
+	for _, name := range w.PostsNames {
+		post := w.Posts[name]
+		values := post.GetCategories()
+		if t.Kind == "tag" {
+			values = post.GetTags()
+		}
+		for _, v := range values {
+			posts[v] = append(posts[v], post)
+		}
+	}
+
+	names := []string{}
+	for name := range posts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names, posts
+}
+
+func (g *Generator) getTaxonomyUrl(t taxonomy, name string) (string, error) {
+	slug := g.slugify(name)
+	if slug == "" {
+		return "", fmt.Errorf("Cannot get url for %s %s", t.Kind, name)
+	}
+
+	pattern := t.Permalink
+	if pattern == "" {
+		pattern = t.DefaultPermalink
+	}
+	url, err := g.expandPermalink(pattern, map[string]string{
+		"name": slug,
+		"slug": slug,
+	})
+	if err != nil {
+		return "", fmt.Errorf("Error getting %s %s url: %w", t.Kind, name, err)
+	}
+	return url, nil
+}
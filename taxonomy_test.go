@@ -0,0 +1,58 @@
+package main
+
+import (
+	"gopkg.in/yaml.v2"
+	"testing"
+)
+
+func TestStringList(t *testing.T) {
+	p := &Page{}
+	if err := yaml.Unmarshal([]byte("categories: one two\ntags:\n  - a b\n  - c\n"), p); err != nil {
+		t.Fatalf("Unmarshal returned error: %s", err.Error())
+	}
+	if len(p.Categories) != 2 || p.Categories[0] != "one" || p.Categories[1] != "two" {
+		t.Fatalf("StringList set invalid categories from string: %v", p.Categories)
+	}
+	if len(p.Tags) != 2 || p.Tags[0] != "a b" || p.Tags[1] != "c" {
+		t.Fatalf("StringList set invalid tags from list: %v", p.Tags)
+	}
+}
+
+func TestGetTaxonomyPages(t *testing.T) {
+	w := &Website{
+		Config: &Config{
+			TagPermalink: "/topics/:name.html",
+		},
+		Layouts: map[string]*Layout{
+			"tag": &Layout{},
+		},
+		Posts: map[string]*Page{
+			"2023-01-01-one": &Page{Title: "One", Url: "/one/", Categories: StringList{"go"}, Tags: StringList{"Web Dev", "go"}},
+			"2022-01-01-two": &Page{Title: "Two", Url: "/two/", Tags: StringList{"go"}},
+		},
+		PostsNames: []string{"2023-01-01-one", "2022-01-01-two"},
+	}
+	g := &Generator{}
+
+	pages, err := g.getTaxonomyPages(w)
+	if err != nil {
+		t.Fatalf("getTaxonomyPages returned error: %s", err.Error())
+	}
+	if len(pages) != 2 {
+		t.Fatalf("getTaxonomyPages returned %d pages instead of 2", len(pages))
+	}
+	if pages[0].Url != "/topics/web-dev.html" || pages[0].Title != "Web Dev" {
+		t.Fatalf("getTaxonomyPages returned invalid page: %v", pages[0])
+	}
+	if pages[1].Url != "/topics/go.html" || pages[1].Layout != "tag" || pages[1].FrontMatter["tag"] != "go" {
+		t.Fatalf("getTaxonomyPages returned invalid page: %v", pages[1])
+	}
+	if posts := pages[1].FrontMatter["posts"].([]interface{}); len(posts) != 2 {
+		t.Fatalf("getTaxonomyPages returned page with %d posts instead of 2", len(posts))
+	}
+
+	w.Config.CategoryLayout = "missing"
+	if _, err := g.getTaxonomyPages(w); err == nil {
+		t.Fatalf("getTaxonomyPages should return error when configured layout does not exist")
+	}
+}
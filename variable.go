@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// splitVariablePath splits variable name such as site.data.menu[0].title into its parts
+func splitVariablePath(s string) []string {
+	re := regexp.MustCompile(`\[[ ]*["']?([^\]"']*)["']?[ ]*\]`)
+	s = re.ReplaceAllString(strings.Trim(s, " "), ".$1")
+	return strings.Split(s, ".")
+}
+
+// getPathValue walks maps and lists in v using path and returns the value found at the end
+func getPathValue(v interface{}, path []string) (interface{}, bool) {
+	for _, key := range path {
+		switch obj := v.(type) {
+		case map[string]interface{}:
+			val, ok := obj[key]
+			if !ok {
+				if key == "size" {
+					v = len(obj)
+					continue
+				}
+				return nil, false
+			}
+			v = val
+		case []interface{}:
+			switch key {
+			case "size":
+				v = len(obj)
+			case "first":
+				if len(obj) == 0 {
+					return nil, false
+				}
+				v = obj[0]
+			case "last":
+				if len(obj) == 0 {
+					return nil, false
+				}
+				v = obj[len(obj)-1]
+			default:
+				i, err := strconv.Atoi(key)
+				if err != nil {
+					return nil, false
+				}
+				if i < 0 {
+					i += len(obj)
+				}
+				if i < 0 || i >= len(obj) {
+					return nil, false
+				}
+				v = obj[i]
+			}
+		case string:
+			if key != "size" {
+				return nil, false
+			}
+			v = len([]rune(obj))
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func valueToString(v interface{}) string {
+	switch val := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return val
+	case bool:
+		return strconv.FormatBool(val)
+	case int:
+		return strconv.Itoa(val)
+	case int64:
+		return strconv.FormatInt(val, 10)
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case []interface{}:
+		s := ""
+		for _, item := range val {
+			s += valueToString(item)
+		}
+		return s
+	case map[string]interface{}:
+		return ""
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+// valueToList returns items to iterate over; maps are turned into a list of [key, value] pairs sorted by key
+func valueToList(v interface{}) []interface{} {
+	switch val := v.(type) {
+	case nil:
+		return []interface{}{}
+	case []interface{}:
+		return val
+	case map[string]interface{}:
+		keys := []string{}
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		list := []interface{}{}
+		for _, k := range keys {
+			list = append(list, []interface{}{k, val[k]})
+		}
+		return list
+	case string:
+		if val == "" {
+			return []interface{}{}
+		}
+	}
+	return []interface{}{v}
+}
+
+func isTruthy(v interface{}) bool {
+	switch val := v.(type) {
+	case nil:
+		return false
+	case bool:
+		return val
+	case string:
+		return val != ""
+	case []interface{}:
+		return len(val) > 0
+	case map[string]interface{}:
+		return len(val) > 0
+	}
+	return true
+}
+
+// valueToDate returns time of a date, eg. 2020-01-01 from front matter or post filename
+func valueToDate(v interface{}) (time.Time, bool) {
+	switch val := v.(type) {
+	case time.Time:
+		return val, true
+	case string:
+		t, err := parseDate(val)
+		return t, err == nil
+	}
+	return time.Time{}, false
+}
+
+// compareValues returns -1, 0 or 1; numbers are compared numerically, dates chronologically and everything
+// else as strings
+func compareValues(a interface{}, b interface{}) int {
+	af, aok := valueToFloat(a)
+	bf, bok := valueToFloat(b)
+	if aok && bok {
+		if af < bf {
+			return -1
+		} else if af > bf {
+			return 1
+		}
+		return 0
+	}
+	at, aok := valueToDate(a)
+	bt, bok := valueToDate(b)
+	if aok && bok {
+		return at.Compare(bt)
+	}
+	return strings.Compare(valueToString(a), valueToString(b))
+}
+
+func valueToFloat(v interface{}) (float64, bool) {
+	switch val := v.(type) {
+	case int:
+		return float64(val), true
+	case int64:
+		return float64(val), true
+	case float64:
+		return val, true
+	case string:
+		f, err := strconv.ParseFloat(strings.Trim(val, " "), 64)
+		if err != nil {
+			return 0, false
+		}
+		return f, true
+	}
+	return 0, false
+}
+
+// normalizeYamlValue converts maps decoded from YAML to map[string]interface{} so that they can be used as
+// variables
+func normalizeYamlValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[interface{}]interface{}:
+		m := map[string]interface{}{}
+		for k, item := range val {
+			m[valueToString(k)] = normalizeYamlValue(item)
+		}
+		return m
+	case map[string]interface{}:
+		m := map[string]interface{}{}
+		for k, item := range val {
+			m[k] = normalizeYamlValue(item)
+		}
+		return m
+	case []interface{}:
+		list := []interface{}{}
+		for _, item := range val {
+			list = append(list, normalizeYamlValue(item))
+		}
+		return list
+	}
+	return v
+}
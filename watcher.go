@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type Watcher struct {
+	Path        string
+	Interval    time.Duration
+	IgnorePaths []string
+}
+
+type watchedFile struct {
+	ModTime int64
+	Size    int64
+}
+
+func (wt *Watcher) Watch(stop <-chan struct{}, onChange func()) error {
+	state, err := wt.scan()
+	if err != nil {
+		return fmt.Errorf("Error scanning %s: %w", wt.Path, err)
+	}
+
+	interval := wt.Interval
+	if interval == 0 {
+		interval = 500 * time.Millisecond
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return nil
+		case <-ticker.C:
+			newState, err := wt.scan()
+			if err != nil {
+				continue
+			}
+			if wt.isChanged(state, newState) {
+				state = newState
+				onChange()
+			}
+		}
+	}
+}
+
+func (wt *Watcher) scan() (map[string]watchedFile, error) {
+	state := map[string]watchedFile{}
+
+	ignored := map[string]bool{}
+	for _, p := range wt.IgnorePaths {
+		abs, err := filepath.Abs(p)
+		if err == nil {
+			ignored[abs] = true
+		}
+	}
+
+	err := filepath.WalkDir(wt.Path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p != wt.Path && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if abs, err := filepath.Abs(p); err == nil && ignored[abs] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		fileInfo, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		state[p] = watchedFile{
+			ModTime: fileInfo.ModTime().UnixNano(),
+			Size:    fileInfo.Size(),
+		}
+		return nil
+	})
+
+	return state, err
+}
+
+func (wt *Watcher) isChanged(oldState map[string]watchedFile, newState map[string]watchedFile) bool {
+	if len(oldState) != len(newState) {
+		return true
+	}
+	for p, f := range newState {
+		if o, ok := oldState[p]; !ok || o != f {
+			return true
+		}
+	}
+	return false
+}
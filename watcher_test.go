@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWatcherScan(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "_posts"), 0750)
+	os.MkdirAll(filepath.Join(dir, ".git"), 0750)
+	os.MkdirAll(filepath.Join(dir, "dist"), 0750)
+	os.WriteFile(filepath.Join(dir, "index.markdown"), []byte("Index!"), 0640)
+	os.WriteFile(filepath.Join(dir, "_posts", "post.markdown"), []byte("Post!"), 0640)
+	os.WriteFile(filepath.Join(dir, ".git", "HEAD"), []byte("Head!"), 0640)
+	os.WriteFile(filepath.Join(dir, "dist", "index.html"), []byte("Dist!"), 0640)
+
+	wt := &Watcher{
+		Path:        dir,
+		IgnorePaths: []string{filepath.Join(dir, "dist")},
+	}
+
+	state1, err := wt.scan()
+	if err != nil || len(state1) != 2 {
+		t.Fatalf("scan failed to return source files")
+	}
+
+	os.WriteFile(filepath.Join(dir, "dist", "index.html"), []byte("Dist changed!"), 0640)
+	state2, _ := wt.scan()
+	if wt.isChanged(state1, state2) {
+		t.Fatalf("isChanged detected change in ignored path")
+	}
+
+	os.WriteFile(filepath.Join(dir, "_posts", "post.markdown"), []byte("Post changed!"), 0640)
+	state3, _ := wt.scan()
+	if !wt.isChanged(state2, state3) {
+		t.Fatalf("isChanged failed to detect changed file")
+	}
+
+	os.Remove(filepath.Join(dir, "index.markdown"))
+	state4, _ := wt.scan()
+	if !wt.isChanged(state3, state4) {
+		t.Fatalf("isChanged failed to detect removed file")
+	}
+}
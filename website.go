@@ -3,16 +3,24 @@ package main
 import (
 	"errors"
 	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"time"
 )
 
 type Website struct {
 	SourcePath string
 	Config     *Config
 
+	// Drafts includes posts from _drafts directory and the ones with published set to false
+	Drafts bool
+	// Future includes posts dated in the future
+	Future bool
+
 	Pages     map[string]*Page
 	PageNames []string
 
@@ -24,6 +32,8 @@ type Website struct {
 
 	Posts      map[string]*Page
 	PostsNames []string
+
+	Data map[string]interface{}
 }
 
 func (w *Website) Init() error {
@@ -47,6 +57,10 @@ func (w *Website) Init() error {
 		return fmt.Errorf("Error initialising posts: %w", err)
 	}
 
+	if err := w.initData(); err != nil {
+		return fmt.Errorf("Error initialising data: %w", err)
+	}
+
 	return nil
 }
 
@@ -134,28 +148,48 @@ func (w *Website) initLayouts() error {
 	return nil
 }
 
+// initIncludes reads includes from _includes directory and its subdirectories, naming them with their path
+// without extension, eg. nav/menu
 func (w *Website) initIncludes() error {
 	w.IncludeNames = []string{}
 	w.Includes = map[string]*Include{}
 
-	names, err := w.getFilenamesWithExtensionsFromDir("_includes")
-	if err != nil {
-		return fmt.Errorf("Error getting layouts: %w", err)
+	p := filepath.Join(w.SourcePath, "_includes")
+	if _, err := os.Stat(p); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("Directory %s does not exist", p)
+		}
+		return fmt.Errorf("Error getting file info for %s: %w", p, err)
 	}
 
-	for _, n := range names {
-		w.IncludeNames = append(w.IncludeNames, n)
-		w.Includes[n] = &Include{
-			Name: n,
+	re := regexp.MustCompile(`^[a-zA-Z0-9\_\-]+\.(html|markdown)$`)
+	return filepath.WalkDir(p, func(fpath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return fmt.Errorf("Error walking %s: %w", fpath, err)
+		}
+		if d.IsDir() || !re.MatchString(d.Name()) {
+			return nil
 		}
 
-		p := filepath.Join(w.SourcePath, "_includes", n+".html")
-		if err := w.Includes[n].SetFromFile(p); err != nil {
-			return fmt.Errorf("Error setting include from %s: %w", p, err)
+		rel, err := filepath.Rel(p, fpath)
+		if err != nil {
+			return fmt.Errorf("Error getting relative path of %s: %w", fpath, err)
+		}
+		n := strings.TrimSuffix(filepath.ToSlash(rel), filepath.Ext(rel))
+		if w.Includes[n] != nil {
+			return fmt.Errorf("There are two includes %s but with different extensions", n)
 		}
-	}
 
-	return nil
+		include := &Include{}
+		if err := include.SetFromFile(fpath); err != nil {
+			return fmt.Errorf("Error setting include from %s: %w", fpath, err)
+		}
+		include.Name = n
+
+		w.IncludeNames = append(w.IncludeNames, n)
+		w.Includes[n] = include
+		return nil
+	})
 }
 
 func (w *Website) initPosts() error {
@@ -177,11 +211,148 @@ func (w *Website) initPosts() error {
 		if err := w.Posts[n].SetFromFile(p); err != nil {
 			return fmt.Errorf("Error setting post from %s: %w", p, err)
 		}
+
+		if err := w.Posts[n].SetTime(); err != nil {
+			return fmt.Errorf("Error setting post %s date: %w", n, err)
+		}
+
+		if !w.isPostPublished(w.Posts[n]) {
+			w.PostsNames = w.PostsNames[:len(w.PostsNames)-1]
+			delete(w.Posts, n)
+		}
+	}
+
+	if w.Drafts {
+		if err := w.initDrafts(); err != nil {
+			return fmt.Errorf("Error initialising drafts: %w", err)
+		}
+	}
+
+	w.sortPosts()
+
+	return nil
+}
+
+// initDrafts adds posts from _drafts directory, dated now so that they appear as the latest ones
+func (w *Website) initDrafts() error {
+	if _, err := os.Stat(filepath.Join(w.SourcePath, "_drafts")); err != nil && os.IsNotExist(err) {
+		return nil
+	}
+
+	names, err := w.getFilenamesWithExtensionsFromDir("_drafts")
+	if err != nil {
+		return fmt.Errorf("Error getting drafts: %w", err)
+	}
+
+	now := time.Now()
+	for _, n := range names {
+		draft := &Page{}
+		p := filepath.Join(w.SourcePath, "_drafts", n+".markdown")
+		if err := draft.SetFromFile(p); err != nil {
+			return fmt.Errorf("Error setting draft from %s: %w", p, err)
+		}
+
+		draft.Name = fmt.Sprintf("%s-%s", now.Format("2006-01-02"), n)
+		draft.Date = now.Format("2006-01-02 15:04:05 -0700")
+		draft.Time = now
+		draft.FrontMatter["draft"] = true
+		if w.Posts[draft.Name] != nil {
+			return fmt.Errorf("Draft %s has the same name as post %s", n, draft.Name)
+		}
+
+		w.PostsNames = append(w.PostsNames, draft.Name)
+		w.Posts[draft.Name] = draft
 	}
 
 	return nil
 }
 
+// isPostPublished returns false for posts with published set to false, unless drafts are included, and for
+// posts dated in the future, unless future posts are included
+func (w *Website) isPostPublished(p *Page) bool {
+	if v, ok := p.FrontMatter["published"]; ok && !isTruthy(v) && !w.Drafts {
+		return false
+	}
+	if p.Time.After(time.Now()) && !w.Future {
+		return false
+	}
+	return true
+}
+
+// sortPosts orders PostsNames by date, newest first
+func (w *Website) sortPosts() {
+	sort.SliceStable(w.PostsNames, func(i, j int) bool {
+		ti := w.Posts[w.PostsNames[i]].Time
+		tj := w.Posts[w.PostsNames[j]].Time
+		if ti.Equal(tj) {
+			return w.PostsNames[i] > w.PostsNames[j]
+		}
+		return ti.After(tj)
+	})
+}
+
+func (w *Website) initData() error {
+	w.Data = map[string]interface{}{}
+
+	p := filepath.Join(w.SourcePath, "_data")
+	if _, err := os.Stat(p); err != nil && os.IsNotExist(err) {
+		return nil
+	}
+
+	data, err := w.getDataFromDir(p)
+	if err != nil {
+		return err
+	}
+	w.Data = data
+
+	return nil
+}
+
+// getDataFromDir reads data files from a directory, mapping subdirectories to nested keys
+func (w *Website) getDataFromDir(p string) (map[string]interface{}, error) {
+	data := map[string]interface{}{}
+
+	entries, err := os.ReadDir(p)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading directory %s: %w", p, err)
+	}
+
+	re := regexp.MustCompile(`^[a-zA-Z0-9\_\-]+\.(yml|yaml|json|csv)$`)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		entryPath := filepath.Join(p, e.Name())
+
+		if e.IsDir() {
+			if _, ok := data[e.Name()]; ok {
+				return nil, fmt.Errorf("There is a directory and a file named %s in %s", e.Name(), p)
+			}
+			subData, err := w.getDataFromDir(entryPath)
+			if err != nil {
+				return nil, err
+			}
+			data[e.Name()] = subData
+			continue
+		}
+
+		if !re.MatchString(e.Name()) {
+			continue
+		}
+
+		dataFile := &DataFile{}
+		if err := dataFile.SetFromFile(entryPath); err != nil {
+			return nil, fmt.Errorf("Error getting data from %s: %w", entryPath, err)
+		}
+		if _, ok := data[dataFile.Name]; ok {
+			return nil, fmt.Errorf("There are two data entries named %s in %s", dataFile.Name, p)
+		}
+		data[dataFile.Name] = dataFile.Value
+	}
+
+	return data, nil
+}
+
 func (w *Website) getFilenamesWithExtensionsFromDir(d string) ([]string, error) {
 	p := fmt.Sprintf("%s/%s", w.SourcePath, d)
 
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestInitData(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "_data", "team"), 0750)
+	os.WriteFile(filepath.Join(dir, "_data", "navigation.yml"), []byte("- title: Home\n  url: /\n- title: About\n  url: /about/\n"), 0640)
+	os.WriteFile(filepath.Join(dir, "_data", "settings.json"), []byte(`{"theme": "dark", "columns": 3}`), 0640)
+	os.WriteFile(filepath.Join(dir, "_data", "projects.csv"), []byte("name,url\nspidey,https://github.com/mikolajgs/spidey\nbroccli,https://github.com/mikolajgs/broccli\n"), 0640)
+	os.WriteFile(filepath.Join(dir, "_data", "team", "members.yaml"), []byte("lead:\n  name: Lead\n"), 0640)
+	os.WriteFile(filepath.Join(dir, "_data", "README.md"), []byte("Ignored"), 0640)
+
+	w := &Website{
+		SourcePath: dir,
+	}
+	if err := w.initData(); err != nil {
+		t.Fatalf("initData returned error: %s", err.Error())
+	}
+
+	lookup := func(name string) interface{} {
+		v, _ := getPathValue(w.Data, splitVariablePath(name))
+		return v
+	}
+	if lookup("navigation[1].url") != "/about/" ||
+		lookup("settings.theme") != "dark" ||
+		lookup("settings.columns") != float64(3) ||
+		lookup("projects.size") != 2 ||
+		lookup("projects[0].name") != "spidey" ||
+		lookup("team.members.lead.name") != "Lead" ||
+		lookup("README") != nil {
+
+		t.Fatalf("initData returned invalid data")
+	}
+
+	os.WriteFile(filepath.Join(dir, "_data", "navigation.json"), []byte(`[]`), 0640)
+	if err := w.initData(); err == nil {
+		t.Fatalf("initData failed to return error on duplicated data file")
+	}
+}
+
+func TestInitPostsDraftsAndFuture(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "_posts"), 0750)
+	os.MkdirAll(filepath.Join(dir, "_drafts"), 0750)
+	os.WriteFile(filepath.Join(dir, "_posts", "2022-01-01-published.markdown"), []byte("---\ntitle: Published\n---\n"), 0640)
+	os.WriteFile(filepath.Join(dir, "_posts", "2022-01-02-unpublished.markdown"), []byte("---\npublished: false\n---\n"), 0640)
+	os.WriteFile(filepath.Join(dir, "_posts", "2999-01-01-future.markdown"), []byte("---\ntitle: Future\n---\n"), 0640)
+	os.WriteFile(filepath.Join(dir, "_drafts", "wip.markdown"), []byte("---\ntitle: Draft\n---\n"), 0640)
+
+	w := &Website{
+		SourcePath: dir,
+	}
+	if err := w.initPosts(); err != nil {
+		t.Fatalf("initPosts returned error: %s", err.Error())
+	}
+	if len(w.PostsNames) != 1 || w.PostsNames[0] != "2022-01-01-published" {
+		t.Fatalf("initPosts returned invalid posts: %v", w.PostsNames)
+	}
+
+	w.Drafts = true
+	w.Future = true
+	if err := w.initPosts(); err != nil {
+		t.Fatalf("initPosts returned error: %s", err.Error())
+	}
+	draftName := time.Now().Format("2006-01-02") + "-wip"
+	if len(w.PostsNames) != 4 || w.PostsNames[0] != "2999-01-01-future" || w.PostsNames[1] != draftName {
+		t.Fatalf("initPosts returned invalid posts with drafts and future: %v", w.PostsNames)
+	}
+	if w.Posts[draftName].FrontMatter["draft"] != true {
+		t.Fatalf("initPosts did not mark draft")
+	}
+}
+
+func TestInitIncludes(t *testing.T) {
+	dir := t.TempDir()
+	os.MkdirAll(filepath.Join(dir, "_includes", "nav"), 0750)
+	os.WriteFile(filepath.Join(dir, "_includes", "footer.html"), []byte("Footer"), 0640)
+	os.WriteFile(filepath.Join(dir, "_includes", "note.markdown"), []byte("*Note*"), 0640)
+	os.WriteFile(filepath.Join(dir, "_includes", "nav", "menu.html"), []byte("Menu"), 0640)
+
+	w := &Website{
+		SourcePath: dir,
+	}
+	if err := w.initIncludes(); err != nil {
+		t.Fatalf("initIncludes returned error: %s", err.Error())
+	}
+	if len(w.Includes) != 3 || w.Includes["footer"].Body != "Footer" || w.Includes["note"].ContentType != "markdown" ||
+		w.Includes["nav/menu"].Body != "Menu" {
+		t.Fatalf("initIncludes returned invalid includes: %v", w.IncludeNames)
+	}
+
+	os.WriteFile(filepath.Join(dir, "_includes", "nav", "menu.markdown"), []byte("Menu"), 0640)
+	if err := w.initIncludes(); err == nil {
+		t.Fatalf("initIncludes should return error when two includes have the same name")
+	}
+}